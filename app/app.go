@@ -38,7 +38,7 @@ func (a *app) restartFunc() {
 	}
 }
 
-// ListenSignal 监听系统信号
+// ListenSignal 监听系统信号 收到关闭或重启信号并回收资源后返回
 func ListenSignal() {
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch,
@@ -57,14 +57,14 @@ func ListenSignal() {
 			signal.Stop(ch) //关闭信号通道
 			a.closeFunc()
 
-			break
+			return
 		case syscall.SIGHUP: //自定义的更新重启信号
 			//fmt.Println("重启信号")
 			signal.Stop(ch) //关闭信号通道
 			a.restartFunc()
 			a.closeFunc()
 
-			break
+			return
 		default:
 			continue
 		}
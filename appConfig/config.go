@@ -7,10 +7,16 @@ import (
 	"github.com/solaa51/swagger/log/bufWriter"
 	"github.com/solaa51/swagger/watchConfig"
 	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
 /**
@@ -19,12 +25,150 @@ import (
 
 var config = &Config{}
 
+// 启动时的配置已加载 之后为热更新
+var loaded bool
+
 // Http http服务配置
 type Http struct {
 	PORT     string `yaml:"port"`  //http 监听端口
 	HTTPS    bool   `yaml:"https"` //是否开启https服务
 	HTTPSKEY string `yaml:"httpsKey"`
 	HTTPSPEM string `yaml:"httpsPem"`
+
+	Listeners []Listener `yaml:"listeners"` //多个监听 配置后忽略port和https
+
+	RedirectAddr string     `yaml:"redirectAddr"` //http跳转https的监听地址 如:80 需开启https 配置listeners时使用redirect监听
+	HSTS         HSTSConfig `yaml:"hsts"`         //https响应的HSTS头
+
+	Certs        []CertPair `yaml:"certs"`        //额外的证书 按SNI选择 未匹配时使用httpsPem
+	CertWarnDays int        `yaml:"certWarnDays"` //证书到期前多少天开始告警 默认30
+}
+
+// CertPair 证书及私钥 配置文件目录下的路径 文件变更后自动重新加载
+type CertPair struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+}
+
+// HSTSConfig Strict-Transport-Security配置 仅在https响应中输出
+type HSTSConfig struct {
+	MaxAge            int  `yaml:"maxAge"`            //有效期秒数 0为不输出 建议31536000
+	IncludeSubDomains bool `yaml:"includeSubDomains"` //包含子域名
+	Preload           bool `yaml:"preload"`           //允许加入浏览器预加载列表 需maxAge不少于一年并包含子域名
+}
+
+// Header 生成响应头的值 未开启时为空
+func (h *HSTSConfig) Header() string {
+	if h.MaxAge <= 0 {
+		return ""
+	}
+
+	v := "max-age=" + strconv.Itoa(h.MaxAge)
+	if h.IncludeSubDomains {
+		v += "; includeSubDomains"
+	}
+	if h.Preload {
+		v += "; preload"
+	}
+
+	return v
+}
+
+// Listener 监听配置
+type Listener struct {
+	Network  string `yaml:"network"`  //tcp或unix 默认tcp
+	Addr     string `yaml:"addr"`     //监听地址 tcp如:8080 127.0.0.1:8080 unix为socket文件路径 相对路径基于程序目录
+	TLS      bool   `yaml:"tls"`      //是否开启https
+	CertFile string `yaml:"certFile"` //证书 配置文件目录下的路径 为空时使用httpsPem
+	KeyFile  string `yaml:"keyFile"`  //证书私钥 为空时使用httpsKey
+	Redirect bool   `yaml:"redirect"` //仅将请求301跳转到https监听 不处理业务
+
+	Certs []CertPair `yaml:"certs"` //额外的证书 按SNI选择 为空时使用http.certs
+}
+
+// CertPairs 监听使用的全部证书 第一个为默认证书
+func (l *Listener) CertPairs() []CertPair {
+	return append([]CertPair{{CertFile: l.CertFile, KeyFile: l.KeyFile}}, l.Certs...)
+}
+
+// 监听地址或类型是否一致 证书变更不需要重启
+func sameListener(a, b Listener) bool {
+	return a.Network == b.Network && a.Addr == b.Addr && a.TLS == b.TLS && a.Redirect == b.Redirect
+}
+
+// AllListeners 全部监听配置 未配置listeners时按port和https生成
+func (h *Http) AllListeners() []Listener {
+	if len(h.Listeners) > 0 {
+		return h.Listeners
+	}
+
+	ls := []Listener{{
+		Network:  "tcp",
+		Addr:     ":" + h.PORT,
+		TLS:      h.HTTPS,
+		CertFile: h.HTTPSPEM,
+		KeyFile:  h.HTTPSKEY,
+		Certs:    h.Certs,
+	}}
+
+	if h.HTTPS && h.RedirectAddr != "" {
+		ls = append(ls, Listener{Network: "tcp", Addr: h.RedirectAddr, Redirect: true})
+	}
+
+	return ls
+}
+
+func (l *Listener) check(h *Http) {
+	if l.Network == "" {
+		l.Network = "tcp"
+	}
+	if l.Network != "tcp" && l.Network != "unix" {
+		bufWriter.Fatal("不支持的监听类型:" + l.Network)
+	}
+
+	if l.Addr == "" {
+		bufWriter.Fatal("请为监听配置地址:addr")
+	}
+	if l.Network == "unix" && !filepath.IsAbs(l.Addr) {
+		l.Addr = appPath.AppDir() + l.Addr
+	}
+
+	if l.Redirect && l.TLS {
+		bufWriter.Fatal("跳转监听不能开启tls", l.Addr)
+	}
+
+	if !l.TLS {
+		return
+	}
+
+	if l.CertFile == "" {
+		l.CertFile = h.HTTPSPEM
+	}
+	if l.KeyFile == "" {
+		l.KeyFile = h.HTTPSKEY
+	}
+	if len(l.Certs) == 0 {
+		l.Certs = h.Certs
+	}
+
+	for _, v := range l.CertPairs() {
+		if _, err := configFiles.GetConfigFile(v.CertFile); err != nil {
+			certError("请为https监听配置证书:certFile", l.Addr, v.CertFile)
+		}
+		if _, err := configFiles.GetConfigFile(v.KeyFile); err != nil {
+			certError("请为https监听配置证书:keyFile", l.Addr, v.KeyFile)
+		}
+	}
+}
+
+// 证书文件无法读取 启动时退出
+// 热更新时仅记录错误 由appServer重新加载证书时判断 加载失败继续使用原证书
+func certError(msg string, args ...any) {
+	if !loaded {
+		bufWriter.Fatal(msg, args...)
+	}
+
+	bufWriter.Error(msg, args...)
 }
 
 // StaticConfig 静态文件及路由匹配配置
@@ -41,6 +185,85 @@ type RateConfig struct {
 	WaitMillisecond int     `yaml:"waitMillisecond"` //允许等待的超时毫秒数
 }
 
+// MetricsConfig 监控指标配置
+type MetricsConfig struct {
+	Path string `yaml:"path"` //Prometheus指标输出路径 如/metrics 为空则不开启
+}
+
+// RequestConfig 请求配置
+type RequestConfig struct {
+	MaxBodySize  int64          `yaml:"maxBodySize"`  //请求body最大字节数 不含上传文件 默认10M
+	Timeout      int            `yaml:"timeout"`      //请求处理超时毫秒数 0为不限制 处理方法不会被中断 返回后输出504
+	RouteTimeout map[string]int `yaml:"routeTimeout"` //按路由规则单独设置超时毫秒数 优先于Timeout 路由规则首尾的/可省略
+	PoolDebug    bool           `yaml:"poolDebug"`    //Context回收调试 回收后不再复用 再次使用时panic 仅用于排查问题
+}
+
+// TimeoutOf 获取路由规则对应的超时时间 0为不限制
+func (r *RequestConfig) TimeoutOf(path string) time.Duration {
+	ms, ok := r.RouteTimeout[path]
+	if !ok {
+		ms = r.Timeout
+	}
+
+	if ms <= 0 {
+		return 0
+	}
+
+	return time.Duration(ms) * time.Millisecond
+}
+
+// UploadConfig 文件上传配置
+type UploadConfig struct {
+	MaxSize int64  `yaml:"maxSize"` //上传请求的最大字节数 默认32M
+	Dir     string `yaml:"dir"`     //上传文件保存目录 相对目录基于程序目录 默认upload/
+}
+
+// WebSocketConfig websocket配置
+type WebSocketConfig struct {
+	Origins        []string `yaml:"origins"`        //允许的来源 如https://a.com a.com *.a.com 为*时不限制 为空时仅允许同源
+	MaxMessageSize int64    `yaml:"maxMessageSize"` //单条消息最大字节数 默认64K
+	PingInterval   int      `yaml:"pingInterval"`   //心跳间隔秒数 超过两个间隔未收到响应时断开 默认30
+	SendQueue      int      `yaml:"sendQueue"`      //每个连接的发送队列长度 队列满时断开连接 默认256
+}
+
+// CheckOrigin 检查websocket请求来源 未携带Origin的非浏览器请求直接通过
+func (w *WebSocketConfig) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	if len(w.Origins) == 0 {
+		return strings.EqualFold(u.Host, r.Host)
+	}
+
+	for _, v := range w.Origins {
+		switch {
+		case v == "*":
+			return true
+		case strings.HasPrefix(v, "*."): //子域名
+			if strings.HasSuffix(strings.ToLower(u.Hostname()), strings.ToLower(v[1:])) {
+				return true
+			}
+		case strings.Contains(v, "://"):
+			if strings.EqualFold(v, u.Scheme+"://"+u.Host) {
+				return true
+			}
+		default:
+			if strings.EqualFold(v, u.Host) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 type Config struct {
 	Http Http `yaml:"http"`
 
@@ -60,6 +283,18 @@ type Config struct {
 	// 全局限流配置
 	Rate RateConfig `yaml:"rateConfig"`
 
+	// 监控指标配置
+	Metrics MetricsConfig `yaml:"metrics"`
+
+	// 请求配置
+	Request RequestConfig `yaml:"request"`
+
+	// 文件上传配置
+	Upload UploadConfig `yaml:"upload"`
+
+	// websocket配置
+	WebSocket WebSocketConfig `yaml:"websocket"`
+
 	//服务实例节点ID
 	ServerId int64 `yaml:"serverId"`
 }
@@ -69,32 +304,41 @@ func Info() *Config {
 	return config
 }
 
+var (
+	changeLock  sync.Mutex
+	changeFuncs []func()
+)
+
+// RegistChange 注册配置热更新后的处理方法
+func RegistChange(f func()) {
+	changeLock.Lock()
+	defer changeLock.Unlock()
+
+	changeFuncs = append(changeFuncs, f)
+}
+
 // 检查http配置
 func (c *Config) checkHttpConfig() {
-	if config.Http.PORT == "" { //初始 服务刚启动 未配置端口
-		if c.Http.PORT == "" {
+	if config.Http.PORT == "" && len(config.Http.Listeners) == 0 { //初始 服务刚启动 未配置端口
+		if c.Http.PORT == "" && len(c.Http.Listeners) == 0 {
 			c.Http.PORT, _ = cFunc.GetFreePort()
 		}
 
 		return
 	}
 
-	if c.Http.PORT == "" { //沿用当前配置
-		c.Http.PORT = config.Http.PORT
-		c.Http.HTTPS = config.Http.HTTPS
-		c.Http.HTTPSKEY = config.Http.HTTPSKEY
-		c.Http.HTTPSPEM = config.Http.HTTPSPEM
+	if c.Http.PORT == "" && len(c.Http.Listeners) == 0 { //沿用当前配置
+		c.Http = config.Http
 		return
 	}
 
-	if c.Http.PORT != config.Http.PORT {
+	if len(c.Http.Listeners) == 0 && c.Http.PORT != config.Http.PORT {
 		bufWriter.Warn("app.yaml配置http端口与当前配置不一致，热更新无法修改端口")
 		c.Http.PORT = config.Http.PORT
 	}
 
-	if c.Http.HTTPS != config.Http.HTTPS ||
-		c.Http.HTTPSKEY != config.Http.HTTPSKEY ||
-		c.Http.HTTPSPEM != config.Http.HTTPSPEM {
+	//监听变更需要重启 证书变更由appServer重新加载
+	if !slices.EqualFunc(c.Http.AllListeners(), config.Http.AllListeners(), sameListener) {
 
 		execFile, _ := filepath.Abs(os.Args[0])
 		bufWriter.Warn(execFile, "app.yaml文件下http服务配置变更触发重启更新，发送热更新信号")
@@ -127,17 +371,67 @@ func (c *Config) check() {
 		bufWriter.Fatal("目录地址不允许出现./字符", c.Static.LocalPath)
 	}
 
-	if c.Http.HTTPS {
+	if c.Request.MaxBodySize <= 0 {
+		c.Request.MaxBodySize = 10 << 20
+	}
+
+	//路由规则不含首尾的/ 兼容按路由列表中/user/export的写法配置
+	if len(c.Request.RouteTimeout) > 0 {
+		rt := make(map[string]int, len(c.Request.RouteTimeout))
+		for k, v := range c.Request.RouteTimeout {
+			rt[strings.Trim(k, "/")] = v
+		}
+		c.Request.RouteTimeout = rt
+	}
+
+	if c.Upload.MaxSize <= 0 {
+		c.Upload.MaxSize = 32 << 20
+	}
+	if c.Upload.Dir == "" {
+		c.Upload.Dir = "upload/"
+	}
+	if strings.Contains(c.Upload.Dir, "./") {
+		bufWriter.Fatal("目录地址不允许出现./字符", c.Upload.Dir)
+	}
+	if !filepath.IsAbs(c.Upload.Dir) {
+		c.Upload.Dir = appPath.AppDir() + c.Upload.Dir
+	}
+
+	if c.WebSocket.MaxMessageSize <= 0 {
+		c.WebSocket.MaxMessageSize = 64 << 10
+	}
+	if c.WebSocket.PingInterval <= 0 {
+		c.WebSocket.PingInterval = 30
+	}
+	if c.WebSocket.SendQueue <= 0 {
+		c.WebSocket.SendQueue = 256
+	}
+
+	if c.Http.CertWarnDays <= 0 {
+		c.Http.CertWarnDays = 30
+	}
+
+	for i := range c.Http.Listeners {
+		c.Http.Listeners[i].check(&c.Http)
+	}
+
+	ls := c.Http.AllListeners()
+	if slices.ContainsFunc(ls, func(l Listener) bool { return l.Redirect }) &&
+		!slices.ContainsFunc(ls, func(l Listener) bool { return l.TLS && l.Network == "tcp" }) {
+		bufWriter.Fatal("跳转https需要配置tcp的https监听")
+	}
+
+	if c.Http.HTTPS && len(c.Http.Listeners) == 0 {
 		if c.Http.HTTPSPEM == "" || c.Http.HTTPSKEY == "" {
-			bufWriter.Fatal("请为https服务配置证书:httpsKey和httpsPem")
+			certError("请为https服务配置证书:httpsKey和httpsPem")
 		}
 
 		if _, err := configFiles.GetConfigFile(c.Http.HTTPSKEY); err != nil {
-			bufWriter.Fatal("请为https服务配置证书:httpsKey")
+			certError("请为https服务配置证书:httpsKey")
 		}
 
 		if _, err := configFiles.GetConfigFile(c.Http.HTTPSPEM); err != nil {
-			bufWriter.Fatal("请为https服务配置证书:httpsPem")
+			certError("请为https服务配置证书:httpsPem")
 		}
 
 		// 兼容embed后 这个地址就无效了
@@ -188,6 +482,7 @@ func newConfig() *Config {
 
 func init() {
 	config = newConfig()
+	loaded = true
 
 	ch, _ := watchConfig.AddWatch(configFiles.GetConfigPath("app.yaml"))
 	go func() {
@@ -196,6 +491,13 @@ func init() {
 			case <-ch:
 				bufWriter.Info(appPath.ConfigDir()+"app.yaml", "文件变更触发更新")
 				config = newConfig()
+
+				changeLock.Lock()
+				fs := changeFuncs
+				changeLock.Unlock()
+				for _, f := range fs {
+					f()
+				}
 			}
 		}
 	}()
@@ -0,0 +1,248 @@
+package appServer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/solaa51/swagger/appConfig"
+	"github.com/solaa51/swagger/configFiles"
+	"github.com/solaa51/swagger/log/bufWriter"
+	"github.com/solaa51/swagger/watchConfig"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 证书管理 握手时按SNI选择证书
+// 证书文件或app.yaml中的证书配置变更后重新加载 加载失败时继续使用原证书 不需要重启进程
+// 到期前certWarnDays天开始每天记录告警
+
+// 已加载的证书
+type certSet struct {
+	def       *tls.Certificate            //默认证书 未匹配SNI时使用
+	names     map[string]*tls.Certificate //证书包含的域名
+	wildcards map[string]*tls.Certificate //通配符证书 *.a.com的键为a.com
+}
+
+type certStore struct {
+	key string //监听标识 配置变更时按此查找对应的证书配置
+
+	certs atomic.Pointer[certSet]
+
+	lock    sync.Mutex
+	pairs   []appConfig.CertPair
+	watched map[string]bool //已监控的证书文件
+}
+
+// 创建监听的证书管理 加载失败时返回错误
+func newCertStore(conf appConfig.Listener) (*certStore, error) {
+	s := &certStore{
+		key:     listenerKey(conf.Network, conf.Addr),
+		watched: make(map[string]bool),
+	}
+
+	if err := s.load(conf.CertPairs()); err != nil {
+		return nil, err
+	}
+
+	appConfig.RegistChange(s.configChanged)
+	go s.warnLoop()
+
+	return s, nil
+}
+
+// GetCertificate 按SNI选择证书 用于tls.Config
+func (s *certStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	set := s.certs.Load()
+
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if cert, ok := set.names[name]; ok {
+		return cert, nil
+	}
+
+	if _, parent, ok := strings.Cut(name, "."); ok {
+		if cert, ok := set.wildcards[parent]; ok {
+			return cert, nil
+		}
+	}
+
+	return set.def, nil
+}
+
+// 加载全部证书 成功后整体替换
+func (s *certStore) load(pairs []appConfig.CertPair) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	set := &certSet{
+		names:     make(map[string]*tls.Certificate),
+		wildcards: make(map[string]*tls.Certificate),
+	}
+
+	for _, pair := range pairs {
+		cert, err := loadCert(pair)
+		if err != nil {
+			return err
+		}
+
+		if set.def == nil {
+			set.def = cert
+		}
+
+		for _, name := range certNames(cert.Leaf) {
+			name = strings.ToLower(name)
+			if strings.HasPrefix(name, "*.") {
+				if _, ok := set.wildcards[name[2:]]; !ok {
+					set.wildcards[name[2:]] = cert
+				}
+			} else if _, ok := set.names[name]; !ok {
+				set.names[name] = cert
+			}
+		}
+	}
+
+	if set.def == nil {
+		return errors.New("未配置证书")
+	}
+
+	s.certs.Store(set)
+	s.pairs = pairs
+
+	//监控新增的证书文件
+	for _, pair := range pairs {
+		for _, f := range []string{pair.CertFile, pair.KeyFile} {
+			if s.watched[f] {
+				continue
+			}
+			s.watched[f] = true
+
+			ch, _ := watchConfig.AddWatch(configFiles.GetConfigPath(f))
+			go s.watch(f, ch)
+		}
+	}
+
+	warnExpire(set)
+
+	return nil
+}
+
+// 证书文件变更时重新加载 证书和私钥分开写入时 第一次加载会失败 等待另一个文件变更后再次加载
+func (s *certStore) watch(file string, ch chan struct{}) {
+	for range ch {
+		s.lock.Lock()
+		inUse := slices.ContainsFunc(s.pairs, func(p appConfig.CertPair) bool {
+			return p.CertFile == file || p.KeyFile == file
+		})
+		pairs := s.pairs
+		s.lock.Unlock()
+
+		if !inUse {
+			continue
+		}
+
+		s.reload(pairs, file+"文件变更")
+	}
+}
+
+// app.yaml变更后 按新的证书配置重新加载
+func (s *certStore) configChanged() {
+	for _, l := range appConfig.Info().Http.AllListeners() {
+		if listenerKey(l.Network, l.Addr) != s.key || !l.TLS {
+			continue
+		}
+
+		pairs := l.CertPairs()
+
+		s.lock.Lock()
+		same := slices.Equal(pairs, s.pairs)
+		s.lock.Unlock()
+
+		if !same {
+			s.reload(pairs, "证书配置变更")
+		}
+		return
+	}
+}
+
+func (s *certStore) reload(pairs []appConfig.CertPair, reason string) {
+	if err := s.load(pairs); err != nil {
+		bufWriter.Error(reason, "重新加载证书失败 继续使用原证书", s.key, err)
+		return
+	}
+
+	bufWriter.Warn(reason, "已重新加载证书", s.key)
+}
+
+// 每天检查一次证书有效期
+func (s *certStore) warnLoop() {
+	t := time.NewTicker(24 * time.Hour)
+	defer t.Stop()
+
+	for range t.C {
+		warnExpire(s.certs.Load())
+	}
+}
+
+// 证书即将到期或已过期时记录告警
+func warnExpire(set *certSet) {
+	days := appConfig.Info().Http.CertWarnDays
+	seen := make(map[*tls.Certificate]bool)
+
+	for _, m := range []map[string]*tls.Certificate{{"": set.def}, set.names, set.wildcards} {
+		for _, cert := range m {
+			if seen[cert] {
+				continue
+			}
+			seen[cert] = true
+
+			left := time.Until(cert.Leaf.NotAfter)
+			names := strings.Join(certNames(cert.Leaf), ",")
+			switch {
+			case left <= 0:
+				bufWriter.Error("证书已过期", names, cert.Leaf.NotAfter.Format(time.DateTime))
+			case left < time.Duration(days)*24*time.Hour:
+				bufWriter.Warn("证书即将到期", names, "剩余"+strconv.Itoa(int(left.Hours()/24))+"天", cert.Leaf.NotAfter.Format(time.DateTime))
+			}
+		}
+	}
+}
+
+func loadCert(pair appConfig.CertPair) (*tls.Certificate, error) {
+	certFile, err := configFiles.GetConfigFile(pair.CertFile)
+	if err != nil {
+		return nil, errors.New("读取证书失败:" + pair.CertFile)
+	}
+	keyFile, err := configFiles.GetConfigFile(pair.KeyFile)
+	if err != nil {
+		return nil, errors.New("读取证书私钥失败:" + pair.KeyFile)
+	}
+
+	cert, err := tls.X509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.New("证书解析失败:" + pair.CertFile + " " + err.Error())
+	}
+
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, errors.New("证书解析失败:" + pair.CertFile + " " + err.Error())
+		}
+	}
+
+	return &cert, nil
+}
+
+// 证书包含的域名 未设置SAN时使用CommonName
+func certNames(leaf *x509.Certificate) []string {
+	if len(leaf.DNSNames) > 0 {
+		return leaf.DNSNames
+	}
+
+	if leaf.Subject.CommonName != "" {
+		return []string{leaf.Subject.CommonName}
+	}
+
+	return nil
+}
@@ -3,12 +3,13 @@ package appServer
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"flag"
 	"github.com/solaa51/swagger/app"
 	"github.com/solaa51/swagger/appConfig"
 	"github.com/solaa51/swagger/appVersion"
 	"github.com/solaa51/swagger/cFunc"
-	"github.com/solaa51/swagger/configFiles"
+	context2 "github.com/solaa51/swagger/context"
 	"github.com/solaa51/swagger/handle"
 	"github.com/solaa51/swagger/log/bufWriter"
 	router "github.com/solaa51/swagger/routerV2"
@@ -43,81 +44,91 @@ func Run() {
 }
 
 func start(restart bool) {
-	var ln net.Listener
-	var httpAddr string
-	var err error
-
+	var inherited map[string]net.Listener
 	if restart {
-		//启动命令中包含参数 热重启时，从socket文件描述符 重新启动一个监听
-		//当存在监听socket时 socket的文件描述符就是3 所以从本进程的3号文件描述符 恢复socket监听
-		f := os.NewFile(3, "")
-		ln, err = net.FileListener(f)
+		//热重启时 从继承的socket文件描述符恢复监听
+		inherited = inheritListeners()
+	}
+
+	var lns []*listener
+	for _, conf := range appConfig.Info().Http.AllListeners() {
+		ln, err := newListener(conf, inherited)
 		if err != nil {
-			bufWriter.Fatal("重启服务失败", err)
+			bufWriter.Fatal("监听失败", conf.Network, conf.Addr, err)
 		}
+		lns = append(lns, ln)
+	}
 
-		addr := ln.Addr().(*net.TCPAddr)
-		httpAddr = ":" + strconv.Itoa(addr.Port)
+	//配置中已移除的监听
+	for _, ln := range inherited {
+		_ = ln.Close()
+	}
 
-		bufWriter.Info("升级重启", os.Args, httpAddr, os.Getpid())
-	} else {
-		httpAddr = getHttpAddr()
-		ln, err = net.Listen("tcp", httpAddr)
-		if err != nil {
-			bufWriter.Fatal("监听端口失败", err)
-		}
+	if restart {
+		bufWriter.Info("升级重启", os.Args, listenAddrs(lns), os.Getpid())
 	}
 
 	//加载路由
 	router.InitRouterSegment()
 
 	server := &http.Server{
-		Addr:    httpAddr,
-		Handler: handle.Handler,
+		Handler: hsts(handle.Handler),
 	}
+	for _, ln := range lns {
+		if ln.conf.Network == "tcp" && !ln.conf.Redirect {
+			server.Addr = ln.conf.Addr
+			break
+		}
+	}
+
+	//跳转https的监听使用单独的server
+	var redirect *http.Server
+	if slices.ContainsFunc(lns, func(ln *listener) bool { return ln.conf.Redirect }) {
+		redirect = &http.Server{
+			Handler: redirectHandler(httpsPort(lns)),
+		}
+	}
+
+	server.RegisterOnShutdown(context2.CloseStreams) //关闭推送连接 避免阻塞平滑关闭
 
 	server.RegisterOnShutdown(func() {
 		bufWriter.SetDefaultBuffer(false) //关闭日志缓冲区
 		bufWriter.CloseDefault()          //关闭日志文件句柄
 	})
 
-	// 启动http服务监听
-	go func() {
-		if appConfig.Info().Http.HTTPS {
-			//兼容embed后 这里不能使用地址，需要改为直接读取内容
-			var config *tls.Config
-			if server.TLSConfig == nil {
-				config = &tls.Config{}
-			} else {
-				config = server.TLSConfig.Clone()
-			}
-			if !slices.Contains(config.NextProtos, "http/1.1") {
-				config.NextProtos = append(config.NextProtos, "http/1.1")
-			}
-
-			config.Certificates = make([]tls.Certificate, 1)
-			certFile, _ := configFiles.GetConfigFile(appConfig.Info().Http.HTTPSPEM)
-			keyFile, _ := configFiles.GetConfigFile(appConfig.Info().Http.HTTPSKEY)
-			config.Certificates[0], err = tls.X509KeyPair(certFile, keyFile)
-			if err != nil {
-				bufWriter.Fatal("证书文件解析失败", err)
+	// 启动http服务监听 除跳转外全部监听共用一个server
+	for _, ln := range lns {
+		go func(ln *listener) {
+			if ln.conf.Redirect {
+				if err := redirect.Serve(ln.ln); !errors.Is(err, http.ErrServerClosed) {
+					bufWriter.Fatal("跳转服务启动失败", err, ln.conf.Addr, os.Getpid())
+				}
+				return
 			}
 
-			ln = tls.NewListener(ln, config)
-
-			//err = server.ServeTLS(ln, appConfig.Info().Http.HTTPSPEM, appConfig.Info().Http.HTTPSKEY)
-		}
+			var sl net.Listener = ln.ln
+			if ln.conf.TLS {
+				config, err := tlsConfig(server, ln.conf)
+				if err != nil {
+					bufWriter.Fatal("证书文件解析失败", ln.conf.Addr, err)
+				}
 
-		err = server.Serve(ln)
+				sl = tls.NewListener(sl, config)
+			}
 
-		bufWriter.Fatal("服务启动失败或已被关闭", err, server.Addr, os.Getpid())
-	}()
+			//Shutdown关闭监听后Serve返回ErrServerClosed 由Shutdown等待进行中的请求结束
+			if err := server.Serve(sl); !errors.Is(err, http.ErrServerClosed) {
+				bufWriter.Fatal("服务启动失败", err, ln.conf.Addr, os.Getpid())
+			}
+		}(ln)
+	}
 
-	bufWriter.Warn("启动服务，监听地址:", server.Addr, "进程ID:", os.Getpid(), "服务名称:", appConfig.Info().AppName, "版本号：", appVersion.Version)
+	bufWriter.Warn("启动服务，监听地址:", listenAddrs(lns), "进程ID:", os.Getpid(), "服务名称:", appConfig.Info().AppName, "版本号：", appVersion.Version)
 
 	ss := &appServer{
-		server:   server,
-		listener: ln,
+		server:    server,
+		redirect:  redirect,
+		listeners: lns,
 	}
 
 	//设置默认日志设置
@@ -130,28 +141,163 @@ func start(restart bool) {
 }
 
 type appServer struct {
-	server   *http.Server //http服务server配置
-	listener net.Listener
+	server    *http.Server //http服务server配置
+	redirect  *http.Server //http跳转https 未配置时为nil
+	listeners []*listener
+}
+
+// 单个监听 ln为未包装tls的原始监听 用于重启时传递文件描述符
+type listener struct {
+	conf appConfig.Listener
+	ln   net.Listener
+}
+
+// 重启时传递的监听列表 与ExtraFiles顺序一致 文件描述符从3开始
+const listenersEnv = "SWAGGER_LISTENERS"
+
+// 监听的唯一标识 重启时按此匹配继承的文件描述符
+func listenerKey(network, addr string) string {
+	return network + "://" + addr
+}
+
+// 创建监听 优先使用继承的文件描述符
+func newListener(conf appConfig.Listener, inherited map[string]net.Listener) (*listener, error) {
+	key := listenerKey(conf.Network, conf.Addr)
+	if ln, ok := inherited[key]; ok {
+		delete(inherited, key)
+		if ul, ok := ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(true) //继承的unix监听默认不删除socket文件
+		}
+		return &listener{conf: conf, ln: ln}, nil
+	}
+
+	if conf.Network == "unix" {
+		//清理异常退出残留的socket文件 仍在使用时不处理
+		if _, err := os.Stat(conf.Addr); err == nil {
+			if c, err := net.Dial("unix", conf.Addr); err == nil {
+				_ = c.Close()
+				return nil, errors.New("socket文件正在使用中")
+			}
+			_ = os.Remove(conf.Addr)
+		}
+	}
+
+	ln, err := net.Listen(conf.Network, conf.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listener{conf: conf, ln: ln}, nil
+}
+
+// 恢复继承的监听 旧版本未传递监听列表时 仅恢复3号文件描述符的tcp监听
+func inheritListeners() map[string]net.Listener {
+	var keys []string
+	if v := os.Getenv(listenersEnv); v != "" {
+		keys = strings.Split(v, ",")
+	}
+	_ = os.Unsetenv(listenersEnv)
+
+	ret := make(map[string]net.Listener)
+	if len(keys) == 0 {
+		ln, err := net.FileListener(os.NewFile(3, ""))
+		if err != nil {
+			bufWriter.Fatal("重启服务失败", err)
+		}
+
+		addr := ln.Addr().(*net.TCPAddr)
+		ret[listenerKey("tcp", ":"+strconv.Itoa(addr.Port))] = ln
+
+		return ret
+	}
+
+	for i, key := range keys {
+		f := os.NewFile(uintptr(3+i), key)
+		ln, err := net.FileListener(f)
+		if err != nil {
+			bufWriter.Fatal("重启服务失败", key, err)
+		}
+		_ = f.Close()
+
+		ret[key] = ln
+	}
+
+	return ret
+}
+
+func listenAddrs(lns []*listener) string {
+	addrs := make([]string, 0, len(lns))
+	for _, ln := range lns {
+		addr := listenerKey(ln.conf.Network, ln.conf.Addr)
+		if ln.conf.TLS {
+			addr += "(tls)"
+		}
+		if ln.conf.Redirect {
+			addr += "(redirect)"
+		}
+		addrs = append(addrs, addr)
+	}
+
+	return strings.Join(addrs, " ")
+}
+
+// 生成监听的tls配置
+func tlsConfig(server *http.Server, conf appConfig.Listener) (*tls.Config, error) {
+	var config *tls.Config
+	if server.TLSConfig == nil {
+		config = &tls.Config{}
+	} else {
+		config = server.TLSConfig.Clone()
+	}
+	if !slices.Contains(config.NextProtos, "http/1.1") {
+		config.NextProtos = append(config.NextProtos, "http/1.1")
+	}
+
+	//证书由certStore按SNI提供 文件变更后自动重新加载
+	store, err := newCertStore(conf)
+	if err != nil {
+		return nil, err
+	}
+	config.Certificates = nil
+	config.GetCertificate = store.GetCertificate
+
+	return config, nil
 }
 
 // 重启服务 启用新进程接收新的请求
 func (s *appServer) restart() {
 	bufWriter.Warn("开始重启服务")
 
-	ln := s.listener.(*net.TCPListener)
+	files := make([]*os.File, 0, len(s.listeners))
+	keys := make([]string, 0, len(s.listeners))
+	for _, ln := range s.listeners {
+		var ff *os.File
+		var err error
+		switch l := ln.ln.(type) {
+		case *net.TCPListener:
+			ff, err = l.File()
+		case *net.UnixListener:
+			l.SetUnlinkOnClose(false) //socket文件由新进程继续使用
+			ff, err = l.File()
+		default:
+			err = errors.New("不支持的监听类型")
+		}
+		if err != nil {
+			bufWriter.Error("获取socket文件描述符失败", ln.conf.Addr, err)
+			return
+		}
 
-	ff, err := ln.File()
-	if err != nil {
-		bufWriter.Error("获取socket文件描述符失败", err)
-		return
+		files = append(files, ff)
+		keys = append(keys, listenerKey(ln.conf.Network, ln.conf.Addr))
 	}
 
 	cmd := exec.Command(os.Args[0], []string{"-g"}...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	cmd.ExtraFiles = []*os.File{ff} //重用原有的socket文件描述符
+	cmd.Env = append(os.Environ(), listenersEnv+"="+strings.Join(keys, ","))
+	cmd.ExtraFiles = files //重用原有的socket文件描述符
 
-	err = cmd.Start()
+	err := cmd.Start()
 	if err != nil {
 		bufWriter.Error("重启启动新进程失败:" + err.Error())
 		return
@@ -167,6 +313,11 @@ func (s *appServer) shutdown() {
 	if err != nil {
 		bufWriter.Error("关闭服务失败:", err)
 	}
+
+	//跳转服务在主服务之后关闭 关闭期间仍可响应跳转
+	if s.redirect != nil {
+		_ = s.redirect.Shutdown(ctx)
+	}
 }
 
 // 监控启动文件的变更
@@ -195,14 +346,6 @@ func (s *appServer) watchSelf() {
 	}()
 }
 
-// 获取http服务启动所需的监听端口号
-func getHttpAddr() string {
-	// 获取配置的http监听端口，默认随机分配
-	addr := ":" + appConfig.Info().Http.PORT
-
-	return addr
-}
-
 // 设置默认日志设置
 func defaultLogSet() {
 	bufWriter.SetDefaultBuffer(true) //开启缓冲区
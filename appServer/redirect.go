@@ -0,0 +1,54 @@
+package appServer
+
+import (
+	"github.com/solaa51/swagger/appConfig"
+	"net"
+	"net/http"
+)
+
+// http跳转https 及https响应的HSTS头
+
+// 跳转到https 保留路径和参数 port为https监听端口 443时省略
+func redirectHandler(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// https请求输出HSTS头 配置热更新后立即生效
+func hsts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			if v := appConfig.Info().Http.HSTS.Header(); v != "" {
+				w.Header().Set("Strict-Transport-Security", v)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// 跳转的目标端口 取第一个tcp的https监听
+func httpsPort(lns []*listener) string {
+	for _, ln := range lns {
+		if ln.conf.TLS && ln.conf.Network == "tcp" {
+			_, port, _ := net.SplitHostPort(ln.conf.Addr)
+			return port
+		}
+	}
+
+	return ""
+}
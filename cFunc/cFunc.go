@@ -3,6 +3,7 @@ package cFunc
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/hex"
@@ -583,6 +584,11 @@ func SignPost(domain string, key string, secret string, control string, method s
 
 // GetPost 发送get 或 post请求 获取数据
 func GetPost(method string, sUrl string, data map[string]string, head map[string]string, cookie []*http.Cookie) (string, error) {
+	return GetPostCtx(context.Background(), method, sUrl, data, head, cookie)
+}
+
+// GetPostCtx 发送get 或 post请求 获取数据 ctx取消或超时时中断请求
+func GetPostCtx(ctx context.Context, method string, sUrl string, data map[string]string, head map[string]string, cookie []*http.Cookie) (string, error) {
 	//请求体数据
 	var postBody *strings.Reader
 	if data != nil {
@@ -595,7 +601,7 @@ func GetPost(method string, sUrl string, data map[string]string, head map[string
 		postBody = strings.NewReader("")
 	}
 
-	req, err := http.NewRequest(method, sUrl, postBody)
+	req, err := http.NewRequestWithContext(ctx, method, sUrl, postBody)
 	if err != nil {
 		return "", err
 	}
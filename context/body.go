@@ -0,0 +1,94 @@
+package context
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// 请求body按需读取 大小由appConfig的request.maxBodySize限制 上传文件由upload.maxSize限制
+
+// ErrBodyTooLarge 请求body超出大小限制
+var ErrBodyTooLarge = errors.New("请求内容超出大小限制")
+
+func isTooLarge(err error) bool {
+	var me *http.MaxBytesError
+	return errors.As(err, &me)
+}
+
+// 是否为json请求
+func (c *Context) isJson() bool {
+	return strings.HasPrefix(c.Request.Header.Get("Content-Type"), "application/json")
+}
+
+// Body 读取并缓存请求body 多次调用返回相同内容
+// 超出大小限制时返回ErrBodyTooLarge 已通过BodyReader读取时返回错误
+func (c *Context) Body() ([]byte, error) {
+	c.alive()
+
+	if c.BodyData != nil {
+		return *c.BodyData, nil
+	}
+
+	if c.bodyErr != nil {
+		return nil, c.bodyErr
+	}
+
+	if c.bodyStreamed {
+		return nil, errors.New("body已通过BodyReader读取")
+	}
+
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(c.Request.Body); err != nil {
+			if isTooLarge(err) {
+				err = ErrBodyTooLarge
+			}
+			c.bodyErr = err
+			return nil, err
+		}
+	}
+	c.BodyData = &body
+
+	return body, nil
+}
+
+// BodyReader 以流的方式读取请求body 不缓存 适用于大文件或转发
+// 已通过Body()读取时 返回缓存内容的Reader
+func (c *Context) BodyReader() io.Reader {
+	c.alive()
+
+	if c.BodyData != nil {
+		return bytes.NewReader(*c.BodyData)
+	}
+
+	c.bodyStreamed = true
+	if c.Request.Body == nil {
+		return http.NoBody
+	}
+
+	return &bodyReader{ctx: c}
+}
+
+// BodyTooLarge 请求body是否超出大小限制
+func (c *Context) BodyTooLarge() bool {
+	return errors.Is(c.bodyErr, ErrBodyTooLarge)
+}
+
+// 记录流式读取时的超限错误
+type bodyReader struct {
+	ctx *Context
+}
+
+func (r *bodyReader) Read(p []byte) (int, error) {
+	n, err := r.ctx.Request.Body.Read(p)
+	if err != nil && isTooLarge(err) {
+		r.ctx.bodyErr = ErrBodyTooLarge
+		err = ErrBodyTooLarge
+	}
+
+	return n, err
+}
@@ -2,14 +2,15 @@ package context
 
 import (
 	"context"
+	"errors"
 	"github.com/gorilla/websocket"
+	"github.com/solaa51/swagger/appConfig"
 	"github.com/solaa51/swagger/cFunc"
 	"github.com/solaa51/swagger/library/valid"
-	"github.com/solaa51/swagger/log/bufWriter"
 	"github.com/solaa51/swagger/snowflake"
-	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 )
@@ -24,21 +25,48 @@ func init() {
 	}
 }
 
+// Param 路由中的命名参数
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params 路由中的命名参数 按路由规则中出现的顺序排列
+type Params []Param
+
+// Get 根据名称获取命名参数值
+func (ps Params) Get(name string) (string, bool) {
+	for _, v := range ps {
+		if v.Key == name {
+			return v.Value, true
+		}
+	}
+
+	return "", false
+}
+
 // Context 处理请求上下文
 type Context struct {
 	StartTime time.Time //记录请求开始处理时间
 
-	Ctx            context.Context
+	Ctx            context.Context //由请求的context派生 客户端断开或超时时取消 数据库 redis 外部请求应传入该ctx
 	ResponseWriter http.ResponseWriter
 
 	RetData any //返回数据
 
 	Request *http.Request
 
-	GetPost  url.Values //get参数与 form-data或者x-www-form-urlencoded合集
-	BodyData *[]byte    //body内包含的数据
+	GetPost url.Values //get参数与 form-data或者x-www-form-urlencoded合集
+
+	// BodyData body内包含的数据 首次调用Body()时读取 未读取时为nil
+	//
+	// Deprecated: body改为按需读取 直接解引用可能panic 使用Body()获取
+	BodyData *[]byte
+
+	Params Params //路由中的命名参数 如user/:id
 
-	Valid *valid.Valid //参数校验
+	Valid       *valid.Valid           //参数校验
+	ValidErrors valid.ValidationErrors //参数校验错误 按请求语言生成错误信息
 
 	StructFuncName string //最终调用的structFuncName 如果为方法则为自定义名称 如果为struct则为struct/method
 
@@ -49,22 +77,28 @@ type Context struct {
 	RetCode   int    //返回码
 
 	CustomRet bool //自定义处理返回信息 跳过统一返回处理
+
+	multipartErr error //解析multipart表单的错误
+	bodyErr      error //读取body的错误
+	bodyStreamed bool  //body已通过BodyReader读取
+	status       int   //已输出的响应状态码
+
+	values   map[any]any        //请求范围内的数据 由Set写入
+	sse      *SSEWriter         //事件流推送
+	cancel   context.CancelFunc //取消超时控制
+	released bool               //已回收
+	copied   bool               //由Copy生成的副本
 }
 
 func NewContext(w http.ResponseWriter, r *http.Request, structFuncName string) *Context {
 	ctx := CtxPool.Get().(*Context)
-	ctx.Ctx = context.Background()
+	ctx.reset()
+	ctx.Ctx = r.Context()
 	ctx.ResponseWriter = w
 	ctx.Request = r
 	ctx.StartTime = time.Now()
 	ctx.RequestId = snowflake.IDInt64()
 	ctx.StructFuncName = structFuncName
-	ctx.BodyData = nil
-	ctx.CustomRet = false
-	ctx.RetError = ""
-	ctx.RetCode = 0
-	ctx.RetData = nil
-	ctx.Valid = nil
 
 	//解析参数
 	ctx.parseParam()
@@ -74,9 +108,16 @@ func NewContext(w http.ResponseWriter, r *http.Request, structFuncName string) *
 }
 
 func (c *Context) parseBody() error {
+	c.alive()
+
 	if c.Valid == nil {
-		if c.Request.Method == "POST" && c.Request.Header.Get("Content-Type") == "application/json" {
-			vid, err := valid.NewValid(c.GetPost, *c.BodyData)
+		if c.isJson() {
+			body, err := c.Body()
+			if err != nil {
+				return err
+			}
+
+			vid, err := valid.NewValid(c.GetPost, body)
 			if err != nil {
 				return err
 			}
@@ -89,7 +130,8 @@ func (c *Context) parseBody() error {
 	return nil
 }
 
-func (c *Context) CheckField(regs []*valid.Regulation) (map[string]any, error) {
+// CheckField 根据规则校验参数 rules为跨参数校验规则
+func (c *Context) CheckField(regs []*valid.Regulation, rules ...valid.Rule) (map[string]any, error) {
 	if len(regs) == 0 {
 		return nil, nil
 	}
@@ -98,7 +140,66 @@ func (c *Context) CheckField(regs []*valid.Regulation) (map[string]any, error) {
 		return nil, err
 	}
 
-	return c.Valid.RegData(regs)
+	data, err := c.Valid.RegData(regs, rules...)
+	return data, c.validError(err)
+}
+
+// CheckRules 按预编译的规则校验参数 规则由valid.NewRules或valid.MustRules创建
+func (c *Context) CheckRules(rs *valid.Rules) (map[string]any, error) {
+	if err := c.parseBody(); err != nil {
+		return nil, err
+	}
+
+	data, err := rs.Check(c.Valid)
+	return data, c.validError(err)
+}
+
+// Bind 将请求参数解析到ptr指向的struct 并按struct的valid标签校验
+// 参数来源依次为 路由命名参数 get post参数 json body 后者覆盖前者
+// 校验失败时返回valid.ValidationErrors 包含全部字段的错误
+func (c *Context) Bind(ptr any) error {
+	c.alive()
+
+	values := make(url.Values, len(c.GetPost)+len(c.Params))
+	for _, v := range c.Params {
+		values.Set(v.Key, v.Value)
+	}
+	for k, v := range c.GetPost {
+		values[k] = v
+	}
+
+	var body []byte
+	if c.isJson() {
+		var err error
+		if body, err = c.Body(); err != nil {
+			return err
+		}
+	}
+
+	return c.validError(valid.Bind(values, body, ptr))
+}
+
+// Lang 根据请求头Accept-Language获取校验错误信息的语言
+func (c *Context) Lang() string {
+	return valid.Lang(c.Request.Header.Get("Accept-Language"))
+}
+
+// 校验错误按请求语言重新生成错误信息 并记录到ValidErrors
+func (c *Context) validError(err error) error {
+	var es valid.ValidationErrors
+	var fe *valid.FieldError
+	switch {
+	case errors.As(err, &es):
+		es = es.Localize(c.Lang())
+		c.ValidErrors = append(c.ValidErrors, es...)
+		return es
+	case errors.As(err, &fe):
+		fe = fe.Localize(c.Lang())
+		c.ValidErrors = append(c.ValidErrors, fe)
+		return fe
+	}
+
+	return err
 }
 
 func (c *Context) ParamDataArrayString(reg *valid.Regulation) (bool, []string, error) {
@@ -106,7 +207,8 @@ func (c *Context) ParamDataArrayString(reg *valid.Regulation) (bool, []string, e
 		return false, nil, err
 	}
 
-	return c.Valid.GetStringArray(reg)
+	exist, value, err := c.Valid.GetStringArray(reg)
+	return exist, value, c.validError(err)
 }
 
 func (c *Context) ParamDataArrayInt(reg *valid.Regulation) (bool, []int64, error) {
@@ -114,7 +216,8 @@ func (c *Context) ParamDataArrayInt(reg *valid.Regulation) (bool, []int64, error
 		return false, nil, err
 	}
 
-	return c.Valid.GetIntArray(reg)
+	exist, value, err := c.Valid.GetIntArray(reg)
+	return exist, value, c.validError(err)
 }
 
 // ParamDataInt 检查参数并返回数字
@@ -123,7 +226,8 @@ func (c *Context) ParamDataInt(reg *valid.Regulation) (bool, int64, error) {
 		return false, 0, err
 	}
 
-	return c.Valid.GetInt64(reg)
+	exist, value, err := c.Valid.GetInt64(reg)
+	return exist, value, c.validError(err)
 }
 
 // ParamDataString 检查参数并返回字符串值
@@ -139,11 +243,102 @@ func (c *Context) ParamDataString(reg *valid.Regulation) (bool, string, error) {
 		return false, "", err
 	}
 
-	return c.Valid.GetString(reg)
+	exist, value, err := c.Valid.GetString(reg)
+	return exist, value, c.validError(err)
+}
+
+// ParamDataFloat 检查参数并返回小数
+func (c *Context) ParamDataFloat(reg *valid.Regulation) (bool, float64, error) {
+	if err := c.parseBody(); err != nil {
+		return false, 0, err
+	}
+
+	exist, value, err := c.Valid.GetFloat64(reg)
+	return exist, value, c.validError(err)
+}
+
+// ParamDataBool 检查参数并返回布尔值
+func (c *Context) ParamDataBool(reg *valid.Regulation) (bool, bool, error) {
+	if err := c.parseBody(); err != nil {
+		return false, false, err
+	}
+
+	exist, value, err := c.Valid.GetBool(reg)
+	return exist, value, c.validError(err)
+}
+
+// ParamDataEnum 检查参数是否在reg.Enum范围内
+func (c *Context) ParamDataEnum(reg *valid.Regulation) (bool, string, error) {
+	if err := c.parseBody(); err != nil {
+		return false, "", err
+	}
+
+	exist, value, err := c.Valid.GetEnum(reg)
+	return exist, value, c.validError(err)
+}
+
+// ParamDataDate 检查日期参数并返回时间戳 格式由reg.Layout指定
+func (c *Context) ParamDataDate(reg *valid.Regulation) (bool, int64, error) {
+	if err := c.parseBody(); err != nil {
+		return false, 0, err
+	}
+
+	exist, value, err := c.Valid.GetDate(reg)
+	return exist, value, c.validError(err)
+}
+
+// ParamDataEmail 检查邮箱参数
+func (c *Context) ParamDataEmail(reg *valid.Regulation) (bool, string, error) {
+	if err := c.parseBody(); err != nil {
+		return false, "", err
+	}
+
+	exist, value, err := c.Valid.GetEmail(reg)
+	return exist, value, c.validError(err)
+}
+
+// ParamDataURL 检查网址参数
+func (c *Context) ParamDataURL(reg *valid.Regulation) (bool, string, error) {
+	if err := c.parseBody(); err != nil {
+		return false, "", err
+	}
+
+	exist, value, err := c.Valid.GetURL(reg)
+	return exist, value, c.validError(err)
+}
+
+// ParamDataIP 检查IP参数
+func (c *Context) ParamDataIP(reg *valid.Regulation) (bool, string, error) {
+	if err := c.parseBody(); err != nil {
+		return false, "", err
+	}
+
+	exist, value, err := c.Valid.GetIP(reg)
+	return exist, value, c.validError(err)
+}
+
+// ParamDataMobile 检查手机号参数
+func (c *Context) ParamDataMobile(reg *valid.Regulation) (bool, string, error) {
+	if err := c.parseBody(); err != nil {
+		return false, "", err
+	}
+
+	exist, value, err := c.Valid.GetMobile(reg)
+	return exist, value, c.validError(err)
+}
+
+// Param 获取路由中的命名参数值 不存在时返回空字符串
+func (c *Context) Param(name string) string {
+	c.alive()
+
+	v, _ := c.Params.Get(name)
+	return v
 }
 
 // AddRetError 向返回错误信息中追加错误内容
 func (c *Context) AddRetError(err error) {
+	c.alive()
+
 	if err == nil {
 		return
 	}
@@ -155,30 +350,49 @@ func (c *Context) AddRetError(err error) {
 	c.RetError = c.RetError + err.Error()
 }
 
-// WebSocket 升级请求为websocket
+// WebSocket 升级请求为websocket 请求来源按appConfig的websocket.origins检查
+// 升级后跳过统一返回处理 连接可在处理方法返回后继续使用 需要房间 广播等功能时使用wsHub
 func (c *Context) WebSocket() (*websocket.Conn, error) {
-	upgrade := websocket.Upgrader{}
-	upgrade.CheckOrigin = func(r *http.Request) bool {
-		return true
+	c.alive()
+
+	upgrade := websocket.Upgrader{
+		CheckOrigin: appConfig.Info().WebSocket.CheckOrigin,
 	}
 
+	c.CustomRet = true
 	conn, err := upgrade.Upgrade(c.ResponseWriter, c.Request, nil)
-	return conn, err
+	if err != nil { //已输出错误信息
+		c.status = http.StatusBadRequest
+		if !upgrade.CheckOrigin(c.Request) {
+			c.status = http.StatusForbidden
+		}
+		return nil, err
+	}
+	c.status = http.StatusSwitchingProtocols
+
+	return conn, nil
 }
 
 // 解析请求get post参数
 func (c *Context) parseParam() {
-	// 解析key=value值
-	_ = c.Request.ParseMultipartForm(32 << 20)
-
-	// 获取body内容
-	if c.Request.Method == "POST" || c.Request.Method == "PUT" || c.Request.Method == "PATCH" {
-		body, err := io.ReadAll(c.Request.Body)
-		if err != nil {
-			bufWriter.Error("读取请求body失败", err, c.Request.URL.String())
+	// 限制请求大小 上传文件使用上传配置
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		limit := appConfig.Info().Request.MaxBodySize
+		if strings.HasPrefix(c.Request.Header.Get("Content-Type"), "multipart/form-data") {
+			limit = appConfig.Info().Upload.MaxSize
+		}
+		c.Request.Body = http.MaxBytesReader(c.ResponseWriter, c.Request.Body, limit)
+	}
+
+	// 解析key=value值 json等其他类型的body不在此读取 由Body()按需读取
+	if err := c.Request.ParseForm(); err != nil && isTooLarge(err) {
+		c.bodyErr = ErrBodyTooLarge
+	}
+	if err := c.Request.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		c.multipartErr = err
+		if isTooLarge(err) {
+			c.bodyErr = ErrBodyTooLarge
 		}
-		c.BodyData = &body
-		defer c.Request.Body.Close()
 	}
 
 	c.GetPost = c.Request.Form
@@ -0,0 +1,61 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"github.com/solaa51/swagger/library/valid"
+	"net/http"
+)
+
+// Error 处理方法返回的错误 包含http状态码和业务码
+//
+//	func (a *Auth) Info(ctx *context.Context) (*model.SysAdmin, error) {
+//		return nil, context.NewError(http.StatusNotFound, 3004, "用户不存在")
+//	}
+type Error struct {
+	Status int    //http状态码
+	Code   int    //业务码 对应返回结构中的code
+	Msg    string //错误信息
+
+	err error //原始错误
+}
+
+// NewError 创建处理错误
+func NewError(status, code int, msg string) *Error {
+	return &Error{Status: status, Code: code, Msg: msg}
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// AsError 将处理方法返回的错误转换为*Error
+// 参数校验错误为400 请求body超限为413 处理超时为504 其他错误为500
+func AsError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	status := http.StatusInternalServerError
+	var es valid.ValidationErrors
+	var fe *valid.FieldError
+	switch {
+	case errors.As(err, &es), errors.As(err, &fe):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrBodyTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	}
+
+	return &Error{Status: status, Msg: err.Error(), err: err}
+}
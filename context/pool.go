@@ -0,0 +1,177 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"github.com/solaa51/swagger/appConfig"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Context回收复用
+// 请求结束后Context被回收 处理方法返回后不能再使用ctx及其中的Request GetPost等
+// 在goroutine中使用时 需在处理方法返回前通过ctx.Copy()获取副本
+// 开启request.poolDebug后 回收的Context不再复用 再次使用时panic 便于排查
+//
+//	cp := ctx.Copy()
+//	go func() {
+//		sendMail(cp.Ctx, cp.ClientIp, cp.GetPost.Get("email"))
+//	}()
+
+const releasedMsg = "context: 请求已结束 Context已回收不能继续使用 在goroutine中使用请调用ctx.Copy()"
+
+// ErrCopyWrite 副本不能输出响应
+var ErrCopyWrite = errors.New("context: Copy生成的副本不能输出响应")
+
+var errCopyBody = errors.New("context: 副本不包含body 请在Copy之前调用Body()")
+
+// 重置全部字段 避免复用时残留上一次请求的数据 values清空后复用
+func (c *Context) reset() {
+	values := c.values
+	clear(values)
+	*c = Context{values: values}
+}
+
+// Release 结束请求并回收Context 由请求处理流程调用 之后不能再使用
+// 副本不回收 调用无效果
+func (c *Context) Release() {
+	if c.copied {
+		return
+	}
+
+	debug := appConfig.Info().Request.PoolDebug
+	if c.released {
+		if debug {
+			panic("context: Context重复回收")
+		}
+		return
+	}
+
+	if c.sse != nil {
+		c.sse.Close()
+	}
+	c.Cancel()
+	c.reset()
+	c.released = true
+
+	if debug { //不再复用 并使后续使用立即panic
+		c.Ctx = releasedCtx{}
+		c.ResponseWriter = releasedWriter{}
+		return
+	}
+
+	CtxPool.Put(c)
+}
+
+// 调试模式下检查Context是否已回收
+func (c *Context) alive() {
+	if c.released && appConfig.Info().Request.PoolDebug {
+		panic(releasedMsg)
+	}
+}
+
+// Copy 生成可在goroutine中使用的副本 需在处理方法返回前调用
+// 副本的Ctx不随请求结束取消 不能输出响应 上传的临时文件在请求结束后删除 需提前保存
+func (c *Context) Copy() *Context {
+	c.alive()
+
+	cp := &Context{
+		StartTime:      c.StartTime,
+		Ctx:            context.WithoutCancel(c.Ctx),
+		ResponseWriter: copyWriter{header: make(http.Header)},
+		RetData:        c.RetData,
+		GetPost:        cloneValues(c.GetPost),
+		Params:         slices.Clone(c.Params),
+		Valid:          c.Valid,
+		ValidErrors:    slices.Clone(c.ValidErrors),
+		StructFuncName: c.StructFuncName,
+		ClientIp:       c.ClientIp,
+		RetError:       c.RetError,
+		RequestId:      c.RequestId,
+		RetCode:        c.RetCode,
+		CustomRet:      c.CustomRet,
+		multipartErr:   c.multipartErr,
+		bodyErr:        c.bodyErr,
+		bodyStreamed:   c.bodyStreamed,
+		status:         c.status,
+		values:         maps.Clone(c.values),
+		copied:         true,
+	}
+
+	if c.BodyData != nil {
+		b := slices.Clone(*c.BodyData)
+		cp.BodyData = &b
+	} else if cp.bodyErr == nil {
+		cp.bodyErr = errCopyBody
+	}
+
+	cp.Request = c.Request.Clone(cp.Ctx)
+	cp.Request.Body = http.NoBody
+
+	return cp
+}
+
+func cloneValues(v url.Values) url.Values {
+	if v == nil {
+		return nil
+	}
+
+	ret := maps.Clone(v)
+	for k, vs := range ret {
+		ret[k] = slices.Clone(vs)
+	}
+
+	return ret
+}
+
+// 副本的ResponseWriter 丢弃输出
+type copyWriter struct {
+	header http.Header
+}
+
+func (w copyWriter) Header() http.Header {
+	return w.header
+}
+
+func (w copyWriter) Write([]byte) (int, error) {
+	return 0, ErrCopyWrite
+}
+
+func (w copyWriter) WriteHeader(int) {}
+
+// 已回收Context的ResponseWriter 调试模式使用
+type releasedWriter struct{}
+
+func (releasedWriter) Header() http.Header {
+	panic(releasedMsg)
+}
+
+func (releasedWriter) Write([]byte) (int, error) {
+	panic(releasedMsg)
+}
+
+func (releasedWriter) WriteHeader(int) {
+	panic(releasedMsg)
+}
+
+// 已回收Context的Ctx 调试模式使用
+type releasedCtx struct{}
+
+func (releasedCtx) Deadline() (time.Time, bool) {
+	panic(releasedMsg)
+}
+
+func (releasedCtx) Done() <-chan struct{} {
+	panic(releasedMsg)
+}
+
+func (releasedCtx) Err() error {
+	panic(releasedMsg)
+}
+
+func (releasedCtx) Value(any) any {
+	panic(releasedMsg)
+}
@@ -0,0 +1,149 @@
+package context
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// 响应输出 调用后跳过统一返回处理 不再输出{msg, code, data}结构
+
+// 写入响应头和状态码 标记为自定义返回
+func (c *Context) writeHeader(status int, contentType string) {
+	c.alive()
+
+	c.CustomRet = true
+	c.status = status
+
+	if contentType != "" {
+		c.ResponseWriter.Header().Set("Content-Type", contentType)
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+// Status 已输出的响应状态码 未通过响应方法输出时为0
+func (c *Context) Status() int {
+	return c.status
+}
+
+// JSON 输出json数据
+func (c *Context) JSON(status int, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.Data(status, "application/json;charset=UTF-8", b)
+
+	return nil
+}
+
+// XML 输出xml数据 包含xml头
+func (c *Context) XML(status int, v any) error {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.Data(status, "application/xml;charset=UTF-8", append([]byte(xml.Header), b...))
+
+	return nil
+}
+
+// String 输出文本
+func (c *Context) String(status int, s string) {
+	c.Data(status, "text/plain;charset=UTF-8", []byte(s))
+}
+
+// Data 按指定的内容类型输出数据
+func (c *Context) Data(status int, contentType string, b []byte) {
+	c.writeHeader(status, contentType)
+	_, _ = c.ResponseWriter.Write(b)
+}
+
+// NoContent 输出204 无内容
+func (c *Context) NoContent() {
+	c.writeHeader(http.StatusNoContent, "")
+}
+
+// Redirect 跳转 status为3xx状态码
+func (c *Context) Redirect(status int, location string) {
+	c.CustomRet = true
+	c.status = status
+	http.Redirect(c.ResponseWriter, c.Request, location, status)
+}
+
+// ServeFile 输出本地文件 支持Range和缓存协商 文件不存在时输出404
+func (c *Context) ServeFile(path string) {
+	c.serveFile(path, "")
+}
+
+// Attachment 以附件形式下载本地文件 name为下载时的文件名 为空则使用原文件名
+//
+//	_ = excel.CreateXls(path, titles, data)
+//	ctx.Attachment(path, "报表.xlsx")
+func (c *Context) Attachment(path, name string) {
+	if name == "" {
+		name = filepath.Base(path)
+	}
+
+	c.serveFile(path, mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+}
+
+func (c *Context) serveFile(path, disposition string) {
+	c.CustomRet = true
+
+	f, err := os.Open(path)
+	if err != nil {
+		c.status = http.StatusNotFound
+		http.NotFound(c.ResponseWriter, c.Request)
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		c.status = http.StatusNotFound
+		http.NotFound(c.ResponseWriter, c.Request)
+		return
+	}
+
+	if disposition != "" {
+		c.ResponseWriter.Header().Set("Content-Disposition", disposition)
+	}
+
+	c.status = http.StatusOK
+	http.ServeContent(c.ResponseWriter, c.Request, fi.Name(), fi.ModTime(), f)
+}
+
+// Stream 流式输出 step每次写入后立即发送给客户端 返回false或客户端断开时结束
+//
+//	ctx.Stream(http.StatusOK, "text/plain", func(w io.Writer) bool {
+//		_, err := w.Write(<-ch)
+//		return err == nil
+//	})
+func (c *Context) Stream(status int, contentType string, step func(w io.Writer) bool) {
+	c.writeHeader(status, contentType)
+
+	rc := http.NewResponseController(c.ResponseWriter)
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		if !step(c.ResponseWriter) {
+			return
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
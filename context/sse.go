@@ -0,0 +1,225 @@
+package context
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 服务端推送 Server-Sent Events
+// 客户端断开 请求超时 或服务关闭时Done()被关闭 处理方法应随之返回
+// 长时间推送需通过request.routeTimeout为该路由设置足够的超时时间
+//
+//	sse, err := ctx.SSE()
+//	if err != nil {
+//		return err
+//	}
+//
+//	for p := range progress(ctx.Ctx, sse.LastEventId()) {
+//		if err = sse.Send(context.SSEEvent{Id: p.Id, Event: "progress", Data: p.Percent}); err != nil {
+//			return nil
+//		}
+//	}
+
+// SSEHeartbeat 心跳间隔 定时发送注释行保持连接 0为不发送
+var SSEHeartbeat = 15 * time.Second
+
+// ErrStreamClosed 推送连接已关闭
+var ErrStreamClosed = errors.New("context: 推送连接已关闭")
+
+// SSEEvent 推送的事件
+type SSEEvent struct {
+	Id    string        //事件ID 客户端重连时通过Last-Event-ID带回
+	Event string        //事件名称 为空时客户端按message处理
+	Data  string        //事件数据 多行时按行拆分发送
+	Retry time.Duration //客户端重连间隔 0为不设置
+}
+
+// SSEWriter 推送写入器 可在多个goroutine中使用
+type SSEWriter struct {
+	ctx    *Context
+	rc     *http.ResponseController
+	done   context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu sync.Mutex //写入锁 心跳与事件不能交叉写入
+}
+
+// 进行中的推送连接 服务关闭时统一关闭
+var streams = struct {
+	sync.Mutex
+	m      map[*SSEWriter]struct{}
+	closed bool
+}{m: make(map[*SSEWriter]struct{})}
+
+// CloseStreams 关闭全部推送连接 服务平滑关闭时调用 之后不能再建立推送连接
+// http.Server.Shutdown会等待请求结束 未关闭的推送连接会阻塞关闭过程
+func CloseStreams() {
+	streams.Lock()
+	defer streams.Unlock()
+
+	streams.closed = true
+	for s := range streams.m {
+		s.cancel()
+	}
+}
+
+// SSE 以事件流输出 设置响应头后立即发送给客户端
+func (c *Context) SSE() (*SSEWriter, error) {
+	c.alive()
+
+	if c.sse != nil {
+		return c.sse, nil
+	}
+
+	s := &SSEWriter{
+		ctx: c,
+		rc:  http.NewResponseController(c.ResponseWriter),
+	}
+	s.done, s.cancel = context.WithCancel(c.Ctx)
+
+	streams.Lock()
+	if streams.closed {
+		streams.Unlock()
+		s.cancel()
+		return nil, ErrStreamClosed
+	}
+	streams.m[s] = struct{}{}
+	streams.Unlock()
+
+	h := c.ResponseWriter.Header()
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") //关闭nginx缓冲
+	c.writeHeader(http.StatusOK, "text/event-stream;charset=UTF-8")
+
+	if err := s.rc.Flush(); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	c.sse = s
+
+	if SSEHeartbeat > 0 {
+		s.wg.Add(1)
+		go s.heartbeat(SSEHeartbeat)
+	}
+
+	return s, nil
+}
+
+// LastEventId 客户端重连时带回的最后一个事件ID 首次连接为空
+// 优先读取请求头Last-Event-ID 其次为参数lastEventId 兼容不支持自定义请求头的客户端
+func (s *SSEWriter) LastEventId() string {
+	if id := s.ctx.Request.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+
+	return s.ctx.Request.URL.Query().Get("lastEventId")
+}
+
+// Done 推送结束时关闭 客户端断开 请求超时 服务关闭或调用Close
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.done.Done()
+}
+
+// Send 发送事件
+func (s *SSEWriter) Send(e SSEEvent) error {
+	var b strings.Builder
+	if e.Id != "" {
+		b.WriteString("id: " + oneLine(e.Id) + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + oneLine(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	return s.write(b.String())
+}
+
+// JSON 发送json数据
+func (s *SSEWriter) JSON(event string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.Send(SSEEvent{Event: event, Data: string(b)})
+}
+
+// Retry 设置客户端重连间隔
+func (s *SSEWriter) Retry(d time.Duration) error {
+	return s.write("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n")
+}
+
+// Close 结束推送 请求结束时自动调用
+func (s *SSEWriter) Close() {
+	s.cancel()
+	s.wg.Wait()
+
+	//等待进行中的写入完成
+	s.mu.Lock()
+	s.mu.Unlock()
+
+	streams.Lock()
+	delete(streams.m, s)
+	streams.Unlock()
+}
+
+// 写入并立即发送 连接已关闭时返回ErrStreamClosed
+func (s *SSEWriter) write(str string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done.Err() != nil {
+		return ErrStreamClosed
+	}
+
+	if _, err := s.ctx.ResponseWriter.Write([]byte(str)); err != nil {
+		s.cancel()
+		return err
+	}
+
+	if err := s.rc.Flush(); err != nil {
+		s.cancel()
+		return err
+	}
+
+	return nil
+}
+
+// 定时发送注释行 避免代理或浏览器因空闲断开连接
+func (s *SSEWriter) heartbeat(d time.Duration) {
+	defer s.wg.Done()
+
+	t := time.NewTicker(d)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.done.Done():
+			return
+		case <-t.C:
+			if s.write(":\n\n") != nil {
+				return
+			}
+		}
+	}
+}
+
+// 去除换行 id和event不能包含换行
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
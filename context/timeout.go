@@ -0,0 +1,57 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 请求超时控制 超时后ctx.Ctx被取消 处理方法返回后输出504
+// 处理方法不会被强制中断 超时前不会输出响应 卡住的处理方法会一直占用连接
+// 需将ctx.Ctx传给数据库 redis 外部请求等耗时操作 循环或等待中需监听ctx.Ctx.Done()并尽快返回
+//
+//	db, _, err := orm.GetDbCtx(ctx.Ctx, "default")
+//	val, ok, err := redis.WithContext(ctx.Ctx).Get("key")
+//	body, err := cFunc.GetPostCtx(ctx.Ctx, "GET", sUrl, nil, nil, nil)
+//
+//	for _, row := range rows {
+//		select {
+//		case <-ctx.Ctx.Done():
+//			return ctx.Ctx.Err()
+//		default:
+//		}
+//		export(row)
+//	}
+
+// SetTimeout 设置请求处理超时时间 从请求开始时计算 d<=0时不限制
+// 多次调用时以较早的截止时间为准
+func (c *Context) SetTimeout(d time.Duration) {
+	c.alive()
+
+	if d <= 0 {
+		return
+	}
+
+	prev := c.cancel
+	ctx, cancel := context.WithDeadline(c.Ctx, c.StartTime.Add(d))
+	c.Ctx = ctx
+	c.cancel = func() {
+		cancel()
+		if prev != nil {
+			prev()
+		}
+	}
+}
+
+// Cancel 取消超时控制 释放计时器 请求结束时调用
+func (c *Context) Cancel() {
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
+
+// Timeout 请求是否已超时
+func (c *Context) Timeout() bool {
+	return errors.Is(c.Ctx.Err(), context.DeadlineExceeded)
+}
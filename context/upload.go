@@ -0,0 +1,127 @@
+package context
+
+import (
+	"errors"
+	"github.com/solaa51/swagger/appConfig"
+	"github.com/solaa51/swagger/library/valid"
+	"github.com/solaa51/swagger/snowflake"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 文件上传 请求大小由appConfig的upload.maxSize限制 保存目录为upload.dir
+
+// Files 获取同名上传的全部文件 未上传时返回空
+func (c *Context) Files(name string) ([]*multipart.FileHeader, error) {
+	c.alive()
+
+	if c.multipartErr != nil {
+		var me *http.MaxBytesError
+		if errors.As(c.multipartErr, &me) {
+			return nil, errors.New("上传文件超出大小限制")
+		}
+		return nil, errors.New("解析上传文件失败:" + c.multipartErr.Error())
+	}
+
+	if c.Request.MultipartForm == nil {
+		return nil, nil
+	}
+
+	return c.Request.MultipartForm.File[name], nil
+}
+
+// File 获取上传的文件 同名多个文件时返回第一个 未上传时返回http.ErrMissingFile
+func (c *Context) File(name string) (*multipart.FileHeader, error) {
+	fhs, err := c.Files(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fhs) == 0 {
+		return nil, http.ErrMissingFile
+	}
+
+	return fhs[0], nil
+}
+
+// ParamDataFile 获取上传的文件并按规则校验 未上传时返回nil
+func (c *Context) ParamDataFile(rule *valid.FileRule) (bool, *multipart.FileHeader, error) {
+	fhs, err := c.Files(rule.Name)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var fh *multipart.FileHeader
+	if len(fhs) > 0 {
+		fh = fhs[0]
+	}
+
+	if err = valid.CheckFile(fh, rule); err != nil {
+		return fh != nil, nil, c.validError(err)
+	}
+
+	return fh != nil, fh, nil
+}
+
+// ParamDataFiles 获取同名上传的全部文件并按规则校验
+func (c *Context) ParamDataFiles(rule *valid.FileRule) (bool, []*multipart.FileHeader, error) {
+	fhs, err := c.Files(rule.Name)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if err = valid.CheckFiles(fhs, rule); err != nil {
+		return len(fhs) > 0, nil, c.validError(err)
+	}
+
+	return len(fhs) > 0, fhs, nil
+}
+
+// SaveFile 保存上传的文件到上传目录 返回保存后的绝对路径
+// dst为上传目录下的相对路径 为空或以/结尾时自动生成文件名 扩展名沿用上传文件
+// dst不允许跳出上传目录
+//
+//	path, err := ctx.SaveFile(fh, "excel/")
+//	rows, err := excel.ReadXls(path)
+func (c *Context) SaveFile(fh *multipart.FileHeader, dst string) (string, error) {
+	dir := filepath.Clean(appConfig.Info().Upload.Dir)
+
+	if dst == "" || strings.HasSuffix(dst, "/") {
+		dst += snowflake.ID() + strings.ToLower(filepath.Ext(fh.Filename))
+	}
+
+	if filepath.IsAbs(dst) {
+		return "", errors.New("保存路径不能为绝对路径")
+	}
+
+	path := filepath.Join(dir, dst)
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("保存路径不能超出上传目录")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	src, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err = io.Copy(f, src); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
@@ -0,0 +1,70 @@
+package context
+
+// 请求范围内的数据存储 用于中间件向处理方法传递数据 请求结束后随Context回收清空
+// 推荐使用带类型的Key 避免字符串键冲突和类型断言
+//
+//	var AdminId = context.NewKey[int64]("adminId")
+//
+//	//中间件中写入
+//	AdminId.Set(ctx, admin.Id)
+//
+//	//处理方法中读取
+//	id, ok := AdminId.Get(ctx)
+//
+//	//字符串键
+//	ctx.Set("adminId", admin.Id)
+//	id, ok := context.Value[int64](ctx, "adminId")
+
+// Set 存储请求范围内的数据 key需可比较 同名覆盖
+func (c *Context) Set(key, value any) {
+	c.alive()
+
+	if c.values == nil {
+		c.values = make(map[any]any, 4)
+	}
+	c.values[key] = value
+}
+
+// Get 获取请求范围内的数据
+func (c *Context) Get(key any) (any, bool) {
+	c.alive()
+
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// Value 获取请求范围内的数据并转换为T 不存在或类型不一致时返回零值和false
+func Value[T any](c *Context, key any) (T, bool) {
+	v, ok := c.Get(key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	t, ok := v.(T)
+	return t, ok
+}
+
+// Key 带类型的键 按指针区分 名称仅用于说明
+type Key[T any] struct {
+	name string
+}
+
+// NewKey 创建带类型的键 一般定义为包级变量
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// Set 存储数据
+func (k *Key[T]) Set(c *Context, value T) {
+	c.Set(k, value)
+}
+
+// Get 获取数据 不存在时返回零值和false
+func (k *Key[T]) Get(c *Context) (T, bool) {
+	return Value[T](c, k)
+}
+
+func (k *Key[T]) String() string {
+	return k.name
+}
@@ -6,7 +6,6 @@ import (
 	"github.com/solaa51/swagger/context"
 	"github.com/solaa51/swagger/example/model"
 	"github.com/solaa51/swagger/library/redis"
-	"github.com/solaa51/swagger/library/valid"
 	"github.com/solaa51/swagger/snowflake"
 	"strconv"
 )
@@ -15,11 +14,14 @@ import (
 
 type Auth struct{}
 
+type loginReq struct {
+	Username string `json:"username" desc:"用户名" valid:"required,min=5,max=20,reg=^[a-zA-Z][a-zA-Z0-9]{4,31}$"`
+	Password string `json:"password" desc:"密码" valid:"required,min=4,max=20"`
+}
+
 func (a *Auth) Login(ctx *context.Context) {
-	data, err := ctx.CheckField([]*valid.Regulation{
-		{Name: "username", Desc: "用户名", CheckType: valid.String, Required: true, Min: 5, Max: 20, Reg: "^[a-zA-Z][a-zA-Z0-9]{4,31}$"},
-		{Name: "password", Desc: "密码", CheckType: valid.String, Required: true, Min: 4, Max: 20},
-	})
+	var req loginReq
+	err := ctx.Bind(&req)
 	if err != nil {
 		ctx.RetCode = 3000
 		ctx.AddRetError(err)
@@ -27,7 +29,7 @@ func (a *Auth) Login(ctx *context.Context) {
 	}
 
 	admin := &model.SysAdmin{}
-	model.Db.Model(&model.SysAdmin{}).Where("username = ? AND is_del = 0", data["username"].(string)).Find(admin)
+	model.Db.Model(&model.SysAdmin{}).Where("username = ? AND is_del = 0", req.Username).Find(admin)
 	if admin.Id == 0 {
 		ctx.RetCode = 3008
 		ctx.AddRetError(errors.New("用户名或密码错误"))
@@ -40,7 +42,7 @@ func (a *Auth) Login(ctx *context.Context) {
 		return
 	}
 
-	if admin.Password != md5Password(data["password"].(string)) {
+	if admin.Password != md5Password(req.Password) {
 		ctx.RetCode = 3008
 		ctx.AddRetError(errors.New("用户名或密码错误"))
 
@@ -60,7 +62,7 @@ func (a *Auth) Login(ctx *context.Context) {
 	})
 
 	redisKey := cFunc.Md5([]byte(snowflake.ID() + "-" + strconv.FormatInt(admin.Id, 10)))
-	err = redis.Set(redis.KeyPrefix()+redisKey, strconv.FormatInt(admin.Id, 10), 86400)
+	err = redis.WithContext(ctx.Ctx).Set(redis.KeyPrefix()+redisKey, strconv.FormatInt(admin.Id, 10), 86400)
 	if err != nil {
 		ctx.RetCode = 3009
 		ctx.AddRetError(errors.New("生成token失败，请找管理员处理"))
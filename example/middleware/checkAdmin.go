@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	context2 "context"
 	"errors"
 	"github.com/solaa51/swagger/context"
 	"github.com/solaa51/swagger/example/model"
@@ -12,6 +11,12 @@ import (
 type CheckAdmin struct {
 }
 
+// 登录用户信息 由CheckAdmin写入
+var (
+	AdminId      = context.NewKey[int64]("adminId")
+	AdminRoleIds = context.NewKey[string]("adminRoleIds")
+)
+
 func (c *CheckAdmin) Handle(ctx *context.Context) bool {
 	tokenStr := ctx.Request.Header.Get("Authorization")
 	if tokenStr == "" {
@@ -26,7 +31,7 @@ func (c *CheckAdmin) Handle(ctx *context.Context) bool {
 		return false
 	}
 
-	adminId, b, err := redis.Get(redis.KeyPrefix() + tokenStr)
+	adminId, b, err := redis.WithContext(ctx.Ctx).Get(redis.KeyPrefix() + tokenStr)
 	if err != nil || !b {
 		ctx.RetCode = 886
 		ctx.RetError = "token已失效"
@@ -35,7 +40,7 @@ func (c *CheckAdmin) Handle(ctx *context.Context) bool {
 
 	//检查用户信息是否合法
 	admin := &model.SysAdmin{}
-	model.Db.Where("id = ? AND id_del = 0", adminId).Find(admin)
+	model.Db.WithContext(ctx.Ctx).Where("id = ? AND id_del = 0", adminId).Find(admin)
 	if admin.Id == 0 {
 		ctx.RetCode = 886
 		ctx.RetError = "无效用户"
@@ -49,8 +54,8 @@ func (c *CheckAdmin) Handle(ctx *context.Context) bool {
 	}
 
 	//登录账号ID
-	tCtx1 := context2.WithValue(ctx.Ctx, "adminId", admin.Id)
-	ctx.Ctx = context2.WithValue(tCtx1, "adminRoleIds", admin.Roles)
+	AdminId.Set(ctx, admin.Id)
+	AdminRoleIds.Set(ctx, admin.Roles)
 
 	lastSecond, err := redis.TTL(redis.KeyPrefix() + tokenStr)
 	if err != nil || lastSecond <= 0 {
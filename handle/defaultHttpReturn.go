@@ -3,6 +3,7 @@ package handle
 import (
 	"encoding/json"
 	"github.com/solaa51/swagger/context"
+	"github.com/solaa51/swagger/library/valid"
 	"net/http"
 )
 
@@ -16,6 +17,27 @@ func (defaultHttpReturn) End404(ctx *context.Context, err error) {
 	ctx.Request.Context().Done()
 }
 
+func (defaultHttpReturn) End405(ctx *context.Context, err error) {
+	ctx.ResponseWriter.WriteHeader(http.StatusMethodNotAllowed)
+	_, _ = ctx.ResponseWriter.Write([]byte(err.Error()))
+
+	ctx.Request.Context().Done()
+}
+
+func (defaultHttpReturn) End413(ctx *context.Context, err error) {
+	ctx.ResponseWriter.WriteHeader(http.StatusRequestEntityTooLarge)
+	_, _ = ctx.ResponseWriter.Write([]byte(err.Error()))
+
+	ctx.Request.Context().Done()
+}
+
+func (defaultHttpReturn) End504(ctx *context.Context, err error) {
+	ctx.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	_, _ = ctx.ResponseWriter.Write([]byte(err.Error()))
+
+	ctx.Request.Context().Done()
+}
+
 func (defaultHttpReturn) End500(ctx *context.Context, err error) {
 	ctx.ResponseWriter.WriteHeader(http.StatusInternalServerError)
 	_, _ = ctx.ResponseWriter.Write([]byte(err.Error()))
@@ -23,11 +45,29 @@ func (defaultHttpReturn) End500(ctx *context.Context, err error) {
 	ctx.Request.Context().Done()
 }
 
-func (defaultHttpReturn) End(ctx *context.Context) {
+func (d defaultHttpReturn) EndError(ctx *context.Context, err *context.Error) {
+	if ctx.CustomRet {
+		return
+	}
+
+	if err.Code != 0 {
+		ctx.RetCode = err.Code
+	}
+	ctx.AddRetError(err)
+
+	d.render(ctx, err.Status)
+}
+
+func (d defaultHttpReturn) End(ctx *context.Context) {
 	if ctx.CustomRet {
 		return
 	}
 
+	d.render(ctx, http.StatusOK)
+}
+
+// 输出统一的返回结构
+func (defaultHttpReturn) render(ctx *context.Context, status int) {
 	if ctx.RetError != "" && ctx.RetCode == 0 {
 		ctx.RetCode = 2000
 	}
@@ -37,14 +77,16 @@ func (defaultHttpReturn) End(ctx *context.Context) {
 	}
 
 	retData, _ := json.Marshal(struct {
-		Msg  string `json:"msg"`
-		Code int    `json:"code"`
-		Data any    `json:"data"`
+		Msg    string                 `json:"msg"`
+		Code   int                    `json:"code"`
+		Data   any                    `json:"data"`
+		Errors valid.ValidationErrors `json:"errors,omitempty"` //参数校验错误
 	}{
-		ctx.RetError, ctx.RetCode, ctx.RetData,
+		ctx.RetError, ctx.RetCode, ctx.RetData, ctx.ValidErrors,
 	})
 
 	ctx.ResponseWriter.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	ctx.ResponseWriter.WriteHeader(status)
 	ctx.ResponseWriter.Write(retData)
 
 	ctx.Request.Context().Done()
@@ -9,6 +9,7 @@ import (
 	"github.com/solaa51/swagger/context"
 	"github.com/solaa51/swagger/limiter"
 	"github.com/solaa51/swagger/log/bufWriter"
+	"github.com/solaa51/swagger/metrics"
 	"github.com/solaa51/swagger/middleware"
 	"github.com/solaa51/swagger/routerV2"
 	"log/slog"
@@ -22,26 +23,81 @@ import (
 // http请求处理器
 
 // HttpReturn 返回处理接口，可自定义实现该接口
+// 其余状态可按需实现下方的可选接口 未实现时按对应状态码由End500输出 处理方法返回的4xx错误由End输出
 type HttpReturn interface {
 	End404(ctx *context.Context, err error)
 	End500(ctx *context.Context, err error)
-	End(ctx *context.Context)
+	End(ctx *context.Context) //ctx.ValidErrors为参数校验错误 可按需输出
+}
+
+// 可选 请求方法不允许 响应头Allow已设置为允许的请求方法
+type httpReturn405 interface {
+	End405(ctx *context.Context, err error)
+}
+
+// 可选 请求body超出大小限制
+type httpReturn413 interface {
+	End413(ctx *context.Context, err error)
+}
+
+// 可选 请求处理超时
+type httpReturn504 interface {
+	End504(ctx *context.Context, err error)
+}
+
+// 可选 处理方法返回的错误 按err.Status输出状态码
+type httpReturnError interface {
+	EndError(ctx *context.Context, err *context.Error)
 }
 
 const (
-	StatusZero     int = 0
-	StatusOk       int = 200
-	StatusNotFound int = 404
-	StatusFail     int = 500
+	StatusZero             int = 0
+	StatusOk               int = 200
+	StatusNotFound         int = 404
+	StatusMethodNotAllowed int = 405
+	StatusEntityTooLarge   int = 413
+	StatusFail             int = 500
+	StatusGatewayTimeout   int = 504
+	StatusHandleError      int = -1 //处理方法返回的错误 状态码由错误决定
 )
 
-// 请求结束处理 记录日志
+// 请求结束处理 记录日志 并回收ctx
 func preEnd(ctx *context.Context, status int, err error) {
+	end(ctx, status, err)
+	ctx.Release()
+}
+
+// 输出响应 记录日志
+func end(ctx *context.Context, status int, err error) {
 	switch status {
 	case StatusFail:
 		Handler.httpReturn.End500(ctx, err)
 	case StatusNotFound:
 		Handler.httpReturn.End404(ctx, err)
+	case StatusMethodNotAllowed:
+		if h, ok := Handler.httpReturn.(httpReturn405); ok {
+			h.End405(ctx, err)
+		} else {
+			endWithStatus(ctx, http.StatusMethodNotAllowed, err)
+		}
+	case StatusEntityTooLarge:
+		if h, ok := Handler.httpReturn.(httpReturn413); ok {
+			h.End413(ctx, err)
+		} else {
+			endWithStatus(ctx, http.StatusRequestEntityTooLarge, err)
+		}
+	case StatusGatewayTimeout:
+		if h, ok := Handler.httpReturn.(httpReturn504); ok {
+			h.End504(ctx, err)
+		} else {
+			endWithStatus(ctx, http.StatusGatewayTimeout, err)
+		}
+	case StatusHandleError:
+		if h, ok := Handler.httpReturn.(httpReturnError); ok {
+			h.EndError(ctx, context.AsError(err))
+		} else {
+			endError(ctx, context.AsError(err))
+		}
 	case StatusZero, StatusOk:
 		Handler.httpReturn.End(ctx)
 	default:
@@ -60,8 +116,70 @@ func preEnd(ctx *context.Context, status int, err error) {
 			slog.String("user-agent", ctx.Request.UserAgent()),
 		)
 	}
+}
 
-	context.CtxPool.Put(ctx)
+// 自定义返回未实现对应方法时 由End500输出 状态码替换为status
+func endWithStatus(ctx *context.Context, status int, err error) {
+	withStatus(ctx, status, func() {
+		Handler.httpReturn.End500(ctx, err)
+	})
+}
+
+// 自定义返回未实现EndError时 5xx由End500输出 其余错误写入返回信息由End输出 状态码均为err.Status
+func endError(ctx *context.Context, err *context.Error) {
+	if ctx.CustomRet {
+		return
+	}
+
+	if err.Status >= http.StatusInternalServerError {
+		endWithStatus(ctx, err.Status, err)
+		return
+	}
+
+	if err.Code != 0 {
+		ctx.RetCode = err.Code
+	}
+	ctx.AddRetError(err)
+
+	withStatus(ctx, err.Status, func() {
+		Handler.httpReturn.End(ctx)
+	})
+}
+
+// 执行f 期间输出的状态码替换为status
+func withStatus(ctx *context.Context, status int, f func()) {
+	w := ctx.ResponseWriter
+	ctx.ResponseWriter = &statusWriter{ResponseWriter: w, status: status}
+	defer func() {
+		ctx.ResponseWriter = w
+	}()
+
+	f()
+}
+
+// 输出时将状态码替换为指定值
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(w.status)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap 供http.ResponseController获取原始ResponseWriter
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
 
 type Handle struct {
@@ -76,6 +194,11 @@ func (h *Handle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		expvar.Handler().ServeHTTP(w, r)
 		return
 	}
+
+	if p := appConfig.Info().Metrics.Path; p != "" && r.URL.Path == p { //Prometheus监控指标
+		metrics.Handler().ServeHTTP(w, r)
+		return
+	}
 	/************/
 
 	//调用全局中间件
@@ -86,11 +209,17 @@ func (h *Handle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	hand, args := router.MatchHandleFunc(r.URL.Path)
+	hand, args, params := router.MatchHandleFunc(r.Method, r.URL.Path)
 	if hand != nil {
+		rt := hand.Router(r.Method)
+		if rt == nil {
+			methodNotAllowed(w, r, hand.Allow())
+			return
+		}
+
 		//全局限流保护
 		if limiter.Allow() {
-			execCall(w, r, hand, args...)
+			execCall(w, r, rt, params, rt.FitArgs(args)...)
 			return
 		} else {
 			w.WriteHeader(http.StatusTooManyRequests)
@@ -108,15 +237,42 @@ func (h *Handle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, f)
 }
 
+// 路由存在但请求方法不匹配 OPTIONS请求直接返回允许的请求方法
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allow []string) {
+	w.Header().Set("Allow", strings.Join(allow, ", "))
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	preEnd(context.NewContext(w, r, ""), StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
 // 执行方法调用
-func execCall(w http.ResponseWriter, r *http.Request, handler *router.Segment, args ...string) {
+func execCall(w http.ResponseWriter, r *http.Request, handler *router.Router, params context.Params, args ...string) {
 	//生成context
-	ctx := context.NewContext(w, r, handler.Router.Handler.StructFuncName)
+	ctx := context.NewContext(w, r, handler.Handler.StructFuncName)
+	ctx.Params = params
+	ctx.SetTimeout(appConfig.Info().Request.TimeoutOf(handler.Path))
+
+	//最后执行 panic时同样取消超时计时 关闭推送连接 并回收ctx
+	defer ctx.Release()
 
 	var err error
 
+	//记录路由调用统计
+	start := ctx.StartTime
+	status := http.StatusOK
+	handler.Stats.Begin()
+	defer func() {
+		handler.Stats.End(status, time.Since(start))
+	}()
+
 	defer func() {
 		if e := recover(); e != nil {
+			status = http.StatusBadGateway
+
 			switch e {
 			default:
 				var buf [4096]byte
@@ -139,22 +295,76 @@ func execCall(w http.ResponseWriter, r *http.Request, handler *router.Segment, a
 		}
 	}()
 
+	//解析表单时请求已超出大小限制
+	if ctx.BodyTooLarge() {
+		status = http.StatusRequestEntityTooLarge
+		end(ctx, StatusEntityTooLarge, context.ErrBodyTooLarge)
+		return
+	}
+
 	//调用中间件处理
-	for _, m := range handler.Router.Middleware {
+	for _, m := range handler.Middleware {
 		if !m.Handle(ctx) {
-			preEnd(ctx, 0, nil)
+			status = retStatus(ctx)
+			end(ctx, 0, nil)
 			return
 		}
 	}
 
 	//调用方法
-	err = handler.Router.Handler.Call(ctx, args...)
+	err = handler.Handler.Call(ctx, args...)
+
+	//处理方法返回的错误
+	var he *context.Error
+	if errors.As(err, &he) {
+		status = he.Status
+		switch {
+		case ctx.CustomRet: //已自行输出响应 如websocket升级失败 仅记录
+			status = retStatus(ctx)
+			end(ctx, StatusHandleError, he)
+		case he.Status == http.StatusNotFound:
+			end(ctx, StatusNotFound, he)
+		case he.Status == http.StatusInternalServerError:
+			end(ctx, StatusFail, he)
+		case he.Status == http.StatusGatewayTimeout:
+			end(ctx, StatusGatewayTimeout, he)
+		default:
+			end(ctx, StatusHandleError, he)
+		}
+		return
+	}
+
 	if err != nil {
-		preEnd(context.NewContext(w, r, handler.Router.Handler.StructFuncName), StatusFail, err)
+		status = http.StatusInternalServerError
+		end(ctx, StatusFail, err)
 		return
 	}
 
-	preEnd(ctx, 0, nil)
+	//处理超时 已自行输出时不再处理
+	if ctx.Timeout() && !ctx.CustomRet && ctx.Status() == 0 {
+		status = http.StatusGatewayTimeout
+		end(ctx, StatusGatewayTimeout, ctx.Ctx.Err())
+		return
+	}
+
+	//处理过程中读取body超出大小限制
+	if ctx.BodyTooLarge() && !ctx.CustomRet {
+		status = http.StatusRequestEntityTooLarge
+		end(ctx, StatusEntityTooLarge, context.ErrBodyTooLarge)
+		return
+	}
+
+	status = retStatus(ctx)
+	end(ctx, 0, nil)
+}
+
+// 通过响应方法输出时 以实际状态码为准 需在回收ctx之前调用
+func retStatus(ctx *context.Context) int {
+	if s := ctx.Status(); s != 0 {
+		return s
+	}
+
+	return http.StatusOK
 }
 
 // 解析静态文件或路由转发给前端
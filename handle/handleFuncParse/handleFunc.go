@@ -71,39 +71,183 @@ func LastHandler() {
 	}
 }
 
+// 入参类型为struct指针时 按字段的param标签从路由命名参数中赋值
+const paramsArg = "params"
+
+// 处理方法的返回值类型
+const (
+	outNone       = iota //无返回值
+	outError             //返回error
+	outValueError        //返回值和error 值作为返回数据
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// 检查返回值类型 支持无返回值 error (T, error)
+func parseOut(t reflect.Type) (int, bool) {
+	switch t.NumOut() {
+	case 0:
+		return outNone, true
+	case 1:
+		return outError, t.Out(0) == errorType
+	case 2:
+		return outValueError, t.Out(1) == errorType
+	}
+
+	return 0, false
+}
+
 // HandleFunc 处理方法
 type HandleFunc struct {
 	StructFuncName string //原始struct名称和方法名称 如果为函数则名称为初始匹配名称
 	methodValue    reflect.Value
-	inType         []string //入参类型
+	inType         []string     //入参类型
+	paramsType     reflect.Type //命名参数绑定的struct类型
+	outType        int          //返回值类型
+}
+
+// ArgNum 按位置传递的参数个数
+func (h *HandleFunc) ArgNum() int {
+	n := 0
+	for _, v := range h.inType[1:] {
+		if v != paramsArg {
+			n++
+		}
+	}
+
+	return n
 }
 
 // Call 调用函数或方法
+// args按位置依次传递给int int64 float64 string类型的入参
+// 存在命名参数struct入参时 多余的args忽略
+// 方法返回的错误转换为*context.Error返回 其他错误为调用失败
+// 方法返回值不为nil时 作为ctx.RetData
 func (h *HandleFunc) Call(ctx *context.Context, args ...string) error {
-	if len(h.inType) != len(args)+1 {
+	num := h.ArgNum()
+	if num > len(args) || (num < len(args) && h.paramsType == nil) {
 		return errors.New("参数不匹配:" + h.StructFuncName)
 	}
 
 	in := make([]reflect.Value, len(h.inType))
 	in[0] = reflect.ValueOf(ctx)
 
+	pos := 0
 	for i := 1; i < len(h.inType); i++ {
+		if h.inType[i] == paramsArg {
+			pv := reflect.New(h.paramsType)
+			if err := bindParams(pv.Elem(), ctx.Params); err != nil {
+				return err
+			}
+			in[i] = pv
+			continue
+		}
+
+		arg := args[pos]
+		pos++
+
 		switch h.inType[i] {
 		case "int":
-			ki, _ := strconv.Atoi(args[i-1])
+			ki, _ := strconv.Atoi(arg)
 			in[i] = reflect.ValueOf(ki)
 		case "int64":
-			ki, _ := strconv.ParseInt(args[i-1], 10, 64)
+			ki, _ := strconv.ParseInt(arg, 10, 64)
 			in[i] = reflect.ValueOf(ki)
 		case "float64":
-			ki, _ := strconv.ParseFloat(args[i-1], 64)
+			ki, _ := strconv.ParseFloat(arg, 64)
 			in[i] = reflect.ValueOf(ki)
 		case "string":
-			in[i] = reflect.ValueOf(args[i-1])
+			in[i] = reflect.ValueOf(arg)
+		}
+	}
+
+	out := h.methodValue.Call(in)
+
+	switch h.outType {
+	case outError:
+		return callError(out[0])
+	case outValueError:
+		if err := callError(out[1]); err != nil {
+			return err
+		}
+		if v := out[0]; !isNil(v) {
+			ctx.RetData = v.Interface()
 		}
 	}
 
-	h.methodValue.Call(in)
+	return nil
+}
+
+func callError(v reflect.Value) error {
+	if v.IsNil() {
+		return nil
+	}
+
+	return context.AsError(v.Interface().(error))
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// 将路由命名参数按名称赋值给struct字段
+// 字段名称取param标签 未设置时为字段名首字母小写 标签为-时跳过
+func bindParams(v reflect.Value, params context.Params) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Tag.Get("param")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name[:1]) + field.Name[1:]
+		}
+
+		value, ok := params.Get(name)
+		if !ok {
+			continue
+		}
+
+		fv := v.Field(i)
+		switch fv.Kind() {
+		case reflect.String:
+			fv.SetString(value)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return errors.New("路由参数类型错误:" + name)
+			}
+			fv.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return errors.New("路由参数类型错误:" + name)
+			}
+			fv.SetUint(n)
+		case reflect.Float32, reflect.Float64:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return errors.New("路由参数类型错误:" + name)
+			}
+			fv.SetFloat(n)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.New("路由参数类型错误:" + name)
+			}
+			fv.SetBool(b)
+		}
+	}
 
 	return nil
 }
@@ -24,6 +24,16 @@ func ParseFuncToRoute(structFuncName string, f func(*context.Context)) *HandleFu
 	}
 }
 
+// ParseFuncEToRoute 解析返回error的函数
+func ParseFuncEToRoute(structFuncName string, f func(*context.Context) error) *HandleFunc {
+	return &HandleFunc{
+		StructFuncName: structFuncName,
+		methodValue:    reflect.ValueOf(f),
+		inType:         []string{""},
+		outType:        outError,
+	}
+}
+
 func ParseStructToRoute(strut ControllerInstance, aliasName string) map[string]*HandleFunc {
 	return parseStruct(strut, aliasName)
 }
@@ -83,20 +93,30 @@ func parseMethod(structName string, typ reflect.Type, tyv reflect.Value) map[str
 			continue
 		}
 
-		//存在返回值的方法 不处理
-		if methodType.Type.NumOut() > 0 {
+		//返回值仅支持 无返回值 error (T, error)
+		outType, ok := parseOut(methodType.Type)
+		if !ok {
 			continue
 		}
 
 		// 入参0 为方法自己 第一个参数必须为*context.Context类型才可以对外
 		if methodType.Type.NumIn() >= 2 {
 			argv := make([]string, 0, methodType.Type.NumIn())
+			var paramsType reflect.Type
 			for j := 1; j < methodType.Type.NumIn(); j++ {
 				if j == 1 {
 					if methodType.Type.In(j).String() == "*context.Context" {
 						argv = append(argv, methodType.Type.In(j).Name())
 					}
 				} else {
+					//struct指针入参 按名称绑定路由命名参数 仅支持一个
+					inType := methodType.Type.In(j)
+					if inType.Kind() == reflect.Ptr && inType.Elem().Kind() == reflect.Struct && paramsType == nil {
+						paramsType = inType.Elem()
+						argv = append(argv, paramsArg)
+						continue
+					}
+
 					typeName := methodType.Type.In(j).Name()
 					switch typeName {
 					case "int", "int64", "float64", "string":
@@ -112,6 +132,8 @@ func parseMethod(structName string, typ reflect.Type, tyv reflect.Value) map[str
 				StructFuncName: structName + "." + methodName,
 				methodValue:    methodValue,
 				inType:         argv,
+				paramsType:     paramsType,
+				outType:        outType,
 			}
 		}
 	}
@@ -29,6 +29,19 @@ func StreamPublish(subject string, body []byte) error {
 	return defaultNats.StreamPublish(subject, body)
 }
 
+func Publish(subject string, body []byte) error {
+	return defaultNats.Publish(subject, body)
+}
+
+func Subscribe(subject string, fn func(body []byte)) error {
+	return defaultNats.Subscribe(subject, fn)
+}
+
+// Default 默认连接 可作为wsHub.Broker使用
+func Default() *Nats {
+	return defaultNats
+}
+
 type Nats struct {
 	nc *ants2.Conn
 }
@@ -89,6 +102,20 @@ func (n *Nats) StreamPublish(subject string, body []byte) error {
 	return nil
 }
 
+// Publish 广播模式 发送消息 不持久化 当前在线的订阅者都会收到
+func (n *Nats) Publish(subject string, body []byte) error {
+	return n.nc.Publish(subject, body)
+}
+
+// Subscribe 广播模式 订阅主题 多开时每个订阅者都会收到消息
+func (n *Nats) Subscribe(subject string, fn func(body []byte)) error {
+	_, err := n.nc.Subscribe(subject, func(msg *ants2.Msg) {
+		fn(msg.Data)
+	})
+
+	return err
+}
+
 func (n *Nats) Close() {
 	_ = n.nc.Drain()
 }
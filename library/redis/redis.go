@@ -8,6 +8,7 @@ import (
 	"github.com/solaa51/swagger/appPath"
 	"github.com/solaa51/swagger/configFiles"
 	"github.com/solaa51/swagger/log/bufWriter"
+	"github.com/solaa51/swagger/metrics"
 	"github.com/solaa51/swagger/watchConfig"
 	"gopkg.in/yaml.v3"
 	"sync"
@@ -16,11 +17,28 @@ import (
 
 type Client struct {
 	*redis.Client
+
+	ctx context.Context //命令使用的ctx 为空时使用context.Background()
+}
+
+// WithContext 返回绑定ctx的客户端 共用连接池 ctx取消或超时时中断命令
+//
+//	redis.WithContext(ctx.Ctx).Get("key")
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{Client: c.Client, ctx: ctx}
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+
+	return c.ctx
 }
 
 // Get bool表示是否存在该key
 func (c *Client) Get(key string) (string, bool, error) {
-	val, err := c.Client.Get(context.Background(), key).Result()
+	val, err := c.Client.Get(c.context(), key).Result()
 
 	if errors.Is(err, redis.Nil) {
 		return "", false, nil
@@ -33,7 +51,7 @@ func (c *Client) Get(key string) (string, bool, error) {
 
 // GetDel Get bool表示是否存在该key
 func (c *Client) GetDel(key string) (string, bool, error) {
-	val, err := c.Client.GetDel(context.Background(), key).Result()
+	val, err := c.Client.GetDel(c.context(), key).Result()
 
 	if errors.Is(err, redis.Nil) {
 		return "", false, nil
@@ -46,18 +64,18 @@ func (c *Client) GetDel(key string) (string, bool, error) {
 
 // Set 0-second表示为没有过期时间
 func (c *Client) Set(key string, value string, second int) error {
-	return c.Client.Set(context.Background(), key, value, time.Second*time.Duration(second)).Err()
+	return c.Client.Set(c.context(), key, value, time.Second*time.Duration(second)).Err()
 }
 
 func (c *Client) Incr(key string) (int64, error) {
-	return c.Client.Incr(context.Background(), key).Result()
+	return c.Client.Incr(c.context(), key).Result()
 }
 func (c *Client) IncrBy(key string, inc int64) (int64, error) {
-	return c.Client.IncrBy(context.Background(), key, inc).Result()
+	return c.Client.IncrBy(c.context(), key, inc).Result()
 }
 
 func (c *Client) IsExists(key string) bool {
-	i, err := c.Client.Exists(context.Background(), key).Result()
+	i, err := c.Client.Exists(c.context(), key).Result()
 	if err != nil {
 		return false
 	}
@@ -71,13 +89,13 @@ func (c *Client) IsExists(key string) bool {
 
 // HSet hash表 any可为slice[成对] map等类型 当second=0时无过期时间
 func (c *Client) HSet(key string, value any, second int) error {
-	err := c.Client.HSet(context.Background(), key, value).Err()
+	err := c.Client.HSet(c.context(), key, value).Err()
 	if err != nil {
 		return err
 	}
 
 	if second > 0 {
-		c.Client.Expire(context.Background(), key, time.Second*time.Duration(second))
+		c.Client.Expire(c.context(), key, time.Second*time.Duration(second))
 	}
 
 	return nil
@@ -85,30 +103,30 @@ func (c *Client) HSet(key string, value any, second int) error {
 
 // HSetNX hash表增加一个键值对
 func (c *Client) HSetNX(key string, field string, value any) error {
-	return c.Client.HSetNX(context.Background(), key, field, value).Err()
+	return c.Client.HSetNX(c.context(), key, field, value).Err()
 }
 
 // HIncrBy hash表字段值 增减
 func (c *Client) HIncrBy(key string, field string, incr int64) (int64, error) {
-	return c.Client.HIncrBy(context.Background(), key, field, incr).Result()
+	return c.Client.HIncrBy(c.context(), key, field, incr).Result()
 }
 
 func (c *Client) HDel(key string, field string) (int64, error) {
-	return c.Client.HDel(context.Background(), key, field).Result()
+	return c.Client.HDel(c.context(), key, field).Result()
 }
 
 // HGetAll 获取hash列表中的所有键值对
 func (c *Client) HGetAll(key string) (map[string]string, error) {
-	return c.Client.HGetAll(context.Background(), key).Result()
+	return c.Client.HGetAll(c.context(), key).Result()
 }
 
 func (c *Client) HGetFieldValue(key string, field string) (string, error) {
-	return c.Client.HGet(context.Background(), key, field).Result()
+	return c.Client.HGet(c.context(), key, field).Result()
 }
 
 // TTL 获取剩余时间
 func (c *Client) TTL(key string) (float64, error) {
-	val, err := c.Client.TTL(context.Background(), key).Result()
+	val, err := c.Client.TTL(c.context(), key).Result()
 	if err != nil {
 		return 0, err
 	}
@@ -117,7 +135,7 @@ func (c *Client) TTL(key string) (float64, error) {
 }
 
 func (c *Client) Expire(key string, second int) {
-	c.Client.Expire(context.Background(), key, time.Second*time.Duration(second))
+	c.Client.Expire(c.context(), key, time.Second*time.Duration(second))
 }
 
 func (c *Client) Del(key string) error {
@@ -125,7 +143,7 @@ func (c *Client) Del(key string) error {
 		return errors.New("无效的redis连接")
 	}
 
-	return c.Client.Del(context.Background(), key).Err()
+	return c.Client.Del(c.context(), key).Err()
 }
 
 func (c *Client) Close() {
@@ -205,6 +223,37 @@ func init() {
 	}()
 
 	app.RegistClose(defaultClient.Close)
+
+	metrics.Register(metrics.CollectorFunc(collectPoolStats))
+}
+
+// 输出默认连接的连接池监控指标
+func collectPoolStats(w *metrics.Writer) {
+	wg.Lock()
+	c := defaultClient
+	wg.Unlock()
+
+	if c == nil || c.Client == nil {
+		return
+	}
+
+	st := c.PoolStats()
+	for _, v := range []struct {
+		name  string
+		typ   string
+		help  string
+		value uint32
+	}{
+		{"swagger_redis_pool_hits_total", "counter", "连接池命中次数", st.Hits},
+		{"swagger_redis_pool_misses_total", "counter", "连接池未命中次数", st.Misses},
+		{"swagger_redis_pool_timeouts_total", "counter", "获取连接超时次数", st.Timeouts},
+		{"swagger_redis_pool_total_connections", "gauge", "连接总数", st.TotalConns},
+		{"swagger_redis_pool_idle_connections", "gauge", "空闲连接数", st.IdleConns},
+		{"swagger_redis_pool_stale_connections_total", "counter", "已清理的失效连接数", st.StaleConns},
+	} {
+		w.Family(v.name, v.typ, v.help)
+		w.Sample(v.name, float64(v.value), "client", "default")
+	}
 }
 
 // KeyPrefix 前缀配置
@@ -212,6 +261,11 @@ func KeyPrefix() string {
 	return keyPrefix
 }
 
+// WithContext 返回绑定ctx的默认客户端
+func WithContext(ctx context.Context) *Client {
+	return defaultClient.WithContext(ctx)
+}
+
 func IsExists(key string) bool {
 	return defaultClient.IsExists(key)
 }
@@ -282,7 +336,7 @@ func NewClient(host, port, name, pass string, db int) (*Client, error) {
 		return nil, errors.New("连接redis失败:" + err.Error())
 	}
 
-	cC := &Client{cc}
+	cC := &Client{Client: cc}
 
 	return cC, nil
 }
@@ -0,0 +1,450 @@
+package valid
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/solaa51/swagger/cFunc"
+	"math"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 将请求参数解析到struct 并按struct标签校验
+//
+// 参数名称依次取 form标签 json标签 字段名称
+// 校验规则取valid标签 多个规则以逗号分隔 规则含义同Regulation
+//
+//	required 必填
+//	min=5 max=20 字符串为长度 数字为大小 数组为每个元素
+//	enum=a|b|c 取值范围
+//	email mobile url ip 格式校验
+//	reg=^[a-z]+$ 正则规则 因正则中可能包含逗号 必须放在最后
+//
+// 参数描述取desc标签 用于错误提示 未设置时为参数名称
+//
+//	type LoginReq struct {
+//		Username string `json:"username" desc:"用户名" valid:"required,min=5,max=20,reg=^[a-zA-Z][a-zA-Z0-9]{4,31}$"`
+//		Password string `json:"password" desc:"密码" valid:"required,min=4,max=20"`
+//	}
+
+// 从struct标签解析出的规则
+type tagRule struct {
+	Regulation
+	hasMin bool
+	enum   []string
+	email  bool
+	mobile bool
+	url    bool
+	ip     bool
+}
+
+// Bind 将url.Values和json数据解析到ptr指向的struct 并校验全部字段 返回全部字段的校验错误
+// json数据优先级高于url.Values
+func Bind(values url.Values, jsonStr []byte, ptr any) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("参数必须为struct的指针类型")
+	}
+
+	present := &presence{values: values}
+	errs := make(ValidationErrors, 0)
+
+	errs = append(errs, bindValues(rv.Elem(), values)...)
+
+	if len(jsonStr) > 0 {
+		if err := json.Unmarshal(jsonStr, &present.keys); err != nil {
+			return errors.New("json格式错误")
+		}
+
+		if err := json.Unmarshal(jsonStr, ptr); err != nil {
+			var te *json.UnmarshalTypeError
+			if !errors.As(err, &te) {
+				return errors.New("json格式错误")
+			}
+			errs = append(errs, newFieldError(te.Field, te.Field, "type", CodeType, ""))
+		}
+	}
+
+	cErrs, err := checkStruct(rv.Elem(), "", present)
+	if err != nil {
+		return err
+	}
+
+	//类型错误的参数不再重复校验
+	for _, e := range cErrs {
+		if !slices.ContainsFunc(errs, func(v *FieldError) bool { return v.Field == e.Field }) {
+			errs = append(errs, e)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// 参数名称 form标签 json标签 字段名称
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"form", "json"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" {
+			return name
+		}
+	}
+
+	return f.Name
+}
+
+// 请求中提交的参数 用于判断字段是否存在
+type presence struct {
+	values url.Values
+	keys   map[string]json.RawMessage //json数据的顶层参数
+}
+
+// 字段是否提交 url.Values按参数名称 json数据按json标签或字段名称
+func (p *presence) has(f reflect.StructField) bool {
+	if _, ok := p.values[fieldName(f)]; ok {
+		return true
+	}
+
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" || len(p.keys) == 0 {
+		return false
+	}
+	if name == "" {
+		name = f.Name
+	}
+
+	if _, ok := p.keys[name]; ok {
+		return true
+	}
+
+	//json解析时参数名称不区分大小写
+	for k := range p.keys {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 将url.Values按参数名称赋值给struct字段
+func bindValues(v reflect.Value, values url.Values) ValidationErrors {
+	errs := make(ValidationErrors, 0)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get("form") == "-" {
+			continue
+		}
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			errs = append(errs, bindValues(v.Field(i), values)...)
+			continue
+		}
+
+		name := fieldName(f)
+		vals, ok := values[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+
+		if err := setField(v.Field(i), vals); err != nil {
+			errs = append(errs, newFieldError(name, descName(f, name), "type", CodeType, ""))
+		}
+	}
+
+	return errs
+}
+
+// 字符串赋值给字段 数组字段支持多个同名参数或逗号分隔
+func setField(fv reflect.Value, vals []string) error {
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		if len(vals) == 1 {
+			vals = strings.Split(vals[0], ",")
+		}
+
+		sl := reflect.MakeSlice(fv.Type(), 0, len(vals))
+		for _, s := range vals {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+
+			ev := reflect.New(fv.Type().Elem()).Elem()
+			if err := setValue(ev, s); err != nil {
+				return err
+			}
+			sl = reflect.Append(sl, ev)
+		}
+		fv.Set(sl)
+
+		return nil
+	}
+
+	return setValue(fv, vals[0])
+}
+
+func setValue(fv reflect.Value, s string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil || fv.OverflowInt(n) {
+			return errors.New("类型错误")
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil || fv.OverflowUint(n) {
+			return errors.New("类型错误")
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return errors.New("类型错误")
+		}
+		fv.SetFloat(n)
+	case reflect.Bool:
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "1", "true", "on", "yes":
+			fv.SetBool(true)
+		case "", "0", "false", "off", "no":
+			fv.SetBool(false)
+		default:
+			return errors.New("类型错误")
+		}
+	case reflect.Ptr:
+		pv := reflect.New(fv.Type().Elem())
+		if err := setValue(pv.Elem(), s); err != nil {
+			return err
+		}
+		fv.Set(pv)
+	default:
+		return errors.New("不支持的类型")
+	}
+
+	return nil
+}
+
+// 参数描述 desc标签 未设置时为参数名称
+func descName(f reflect.StructField, name string) string {
+	if desc := f.Tag.Get("desc"); desc != "" {
+		return desc
+	}
+
+	return name
+}
+
+// 解析valid标签
+func parseTag(f reflect.StructField, name string) (*tagRule, error) {
+	r := &tagRule{
+		Regulation: Regulation{Name: name, Desc: descName(f, name)},
+	}
+
+	tag := f.Tag.Get("valid")
+	for tag != "" {
+		var item string
+		if strings.HasPrefix(tag, "reg=") {
+			item, tag = tag, ""
+		} else {
+			item, tag, _ = strings.Cut(tag, ",")
+		}
+
+		key, val, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch key {
+		case "":
+		case "required":
+			r.Required = true
+		case "min", "max":
+			n, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return nil, errors.New("valid标签格式错误:" + f.Name + " " + item)
+			}
+			if key == "min" {
+				r.Min, r.hasMin = n, true
+			} else {
+				r.Max = n
+			}
+		case "enum":
+			r.enum = strings.Split(val, "|")
+		case "email":
+			r.email = true
+		case "mobile":
+			r.mobile = true
+		case "url":
+			r.url = true
+		case "ip":
+			r.ip = true
+		case "reg":
+			r.Reg = val
+		default:
+			return nil, errors.New("valid标签不支持的规则:" + f.Name + " " + item)
+		}
+	}
+
+	//未设置最小值时 数字不限制最小值
+	if !r.hasMin {
+		r.Min = math.MinInt64
+	}
+
+	return r, nil
+}
+
+// 按valid标签校验struct的全部字段 标签格式错误时返回error
+func checkStruct(v reflect.Value, prefix string, present *presence) (ValidationErrors, error) {
+	errs := make(ValidationErrors, 0)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			es, err := checkStruct(fv, prefix, present)
+			if err != nil {
+				return nil, err
+			}
+			errs = append(errs, es...)
+			continue
+		}
+
+		name := fieldName(f)
+		r, err := parseTag(f, prefix+name)
+		if err != nil {
+			return nil, err
+		}
+
+		//嵌套struct 以上级参数名称为前缀 无法判断参数是否存在 以零值判断
+		if fv.Kind() == reflect.Struct {
+			if r.Required && fv.IsZero() {
+				errs = append(errs, r.newError("required", CodeRequired, ""))
+				continue
+			}
+			es, err := checkStruct(fv, r.Name+".", nil)
+			if err != nil {
+				return nil, err
+			}
+			errs = append(errs, es...)
+			continue
+		}
+
+		exist := fv.Kind() != reflect.Ptr || !fv.IsNil()
+		if present != nil {
+			exist = exist && present.has(f)
+		} else {
+			exist = exist && !fv.IsZero()
+		}
+
+		if fe := r.check(fv, exist); fe != nil {
+			errs = append(errs, fe)
+		}
+	}
+
+	return errs, nil
+}
+
+// 按字段类型校验值
+func (r *tagRule) check(fv reflect.Value, exist bool) *FieldError {
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			if r.Required {
+				return r.newError("required", CodeRequired, "")
+			}
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	if r.Required && (!exist || (fv.Kind() == reflect.String && fv.Len() == 0) || (fv.Kind() == reflect.Slice && fv.Len() == 0)) {
+		return r.newError("required", CodeRequired, "")
+	}
+
+	if !exist {
+		return nil
+	}
+
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < fv.Len(); i++ {
+			if fe := r.checkValue(fv.Index(i)); fe != nil {
+				return fe
+			}
+		}
+		return nil
+	}
+
+	return r.checkValue(fv)
+}
+
+func (r *tagRule) checkValue(fv reflect.Value) *FieldError {
+	switch fv.Kind() {
+	case reflect.String:
+		s := fv.String()
+		if fe := r.checkString(s); fe != nil {
+			return fe
+		}
+		return r.checkFormat(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fe := r.checkInt(fv.Int()); fe != nil {
+			return fe
+		}
+		return r.checkFormat(strconv.FormatInt(fv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n := fv.Uint()
+		if n > math.MaxInt64 {
+			n = math.MaxInt64
+		}
+		if fe := r.checkInt(int64(n)); fe != nil {
+			return fe
+		}
+		return r.checkFormat(strconv.FormatUint(fv.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		n := fv.Float()
+		if r.hasMin && n < float64(r.Min) {
+			return r.newError("min", CodeTooSmall, strconv.FormatInt(r.Min, 10))
+		}
+		if r.Max > 0 && n > float64(r.Max) {
+			return r.newError("max", CodeTooLarge, strconv.FormatInt(r.Max, 10))
+		}
+		return r.checkFormat(strconv.FormatFloat(n, 'f', -1, 64))
+	}
+
+	return nil
+}
+
+// 校验取值范围 邮箱 手机号 网址 IP
+func (r *tagRule) checkFormat(s string) *FieldError {
+	if len(r.enum) > 0 && !slices.Contains(r.enum, s) {
+		return r.newError("enum", CodeEnum, strings.Join(r.enum, ","))
+	}
+
+	if s == "" {
+		return nil
+	}
+
+	if r.email && !isEmail(s) {
+		return r.newError("email", CodeEmail, "")
+	}
+
+	if r.mobile && !cFunc.CheckMobile(s) {
+		return r.newError("mobile", CodeMobile, "")
+	}
+
+	if r.url && !isURL(s) {
+		return r.newError("url", CodeURL, "")
+	}
+
+	if r.ip && !isIP(s) {
+		return r.newError("ip", CodeIP, "")
+	}
+
+	return nil
+}
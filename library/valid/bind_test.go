@@ -0,0 +1,65 @@
+package valid
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBind(t *testing.T) {
+	type loginReq struct {
+		Username string   `json:"username" desc:"用户名" valid:"required,min=5,max=20,reg=^[a-zA-Z][a-zA-Z0-9,]*$"`
+		Age      int      `json:"age" desc:"年龄" valid:"min=18,max=60"`
+		Email    string   `json:"email" valid:"email"`
+		Role     string   `form:"role" valid:"enum=admin|user"`
+		Tags     []string `json:"tags" valid:"max=3"`
+	}
+
+	var req loginReq
+	err := Bind(map[string][]string{"role": {"user"}}, []byte(`{"username":"solaa51","age":20,"tags":["a","bc"]}`), &req)
+	if err != nil || req.Username != "solaa51" || req.Age != 20 || req.Role != "user" || len(req.Tags) != 2 {
+		t.Fatalf("Bind %+v %v", req, err)
+	}
+
+	req = loginReq{}
+	err = Bind(map[string][]string{"role": {"guest"}}, []byte(`{"username":"ab","age":"x","email":"a","tags":["abcd"]}`), &req)
+	var es ValidationErrors
+	if !errors.As(err, &es) {
+		t.Fatalf("期望校验错误 实际 %v", err)
+	}
+
+	got := make(map[string]string, len(es))
+	for _, e := range es {
+		got[e.Field] = e.Code
+	}
+	want := map[string]string{"username": CodeTooShort, "age": CodeType, "email": CodeEmail, "role": CodeEnum, "tags": CodeTooLong}
+	for k, code := range want {
+		if got[k] != code {
+			t.Errorf("%s 错误码 %q 期望 %q 全部错误 %v", k, got[k], code, got)
+		}
+	}
+
+	if err = Bind(nil, nil, req); err == nil {
+		t.Error("非指针参数应返回错误")
+	}
+}
+
+// form标签与json标签不同时 从任一来源提交均视为存在
+func TestBindMixedTag(t *testing.T) {
+	type userReq struct {
+		Username string `form:"user_name" json:"username" desc:"用户名" valid:"required"`
+		Nickname string `form:"nick" json:"nickname" valid:"required"`
+	}
+
+	var req userReq
+	err := Bind(map[string][]string{"nick": {"sola"}}, []byte(`{"username":"solaa51"}`), &req)
+	if err != nil || req.Username != "solaa51" || req.Nickname != "sola" {
+		t.Fatalf("Bind %+v %v", req, err)
+	}
+
+	req = userReq{}
+	err = Bind(nil, []byte(`{"Nickname":"sola"}`), &req)
+	var es ValidationErrors
+	if !errors.As(err, &es) || len(es) != 1 || es[0].Field != "user_name" || es[0].Code != CodeRequired {
+		t.Errorf("期望 user_name %s 实际 %v", CodeRequired, err)
+	}
+}
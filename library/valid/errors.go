@@ -0,0 +1,248 @@
+package valid
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 校验错误 包含参数名称 未通过的规则 期望值和固定的错误码 便于前端处理
+// 错误信息由模板生成 模板按语言区分 可通过SetMessage自定义
+
+// 错误码 固定不变 可用于前端判断
+const (
+	CodeRequired   = "required"    //必填
+	CodeType       = "type"        //类型错误
+	CodeTooShort   = "too_short"   //字符串长度不足
+	CodeTooLong    = "too_long"    //字符串长度超出
+	CodeTooSmall   = "too_small"   //数值过小
+	CodeTooLarge   = "too_large"   //数值过大
+	CodeTooFew     = "too_few"     //数组元素个数不足
+	CodeTooMany    = "too_many"    //数组元素个数超出
+	CodePattern    = "pattern"     //正则校验失败
+	CodeEnum       = "enum"        //不在取值范围内
+	CodeEmail      = "email"       //邮箱格式错误
+	CodeMobile     = "mobile"      //手机号格式错误
+	CodeURL        = "url"         //网址格式错误
+	CodeIP         = "ip"          //IP格式错误
+	CodeDate       = "date"        //日期格式错误
+	CodePrecision  = "precision"   //小数位数超出
+	CodeEqField    = "eq_field"    //与关联参数不相等
+	CodeNeField    = "ne_field"    //与关联参数相等
+	CodeGtField    = "gt_field"    //不大于关联参数
+	CodeGteField   = "gte_field"   //小于关联参数
+	CodeLtField    = "lt_field"    //不小于关联参数
+	CodeLteField   = "lte_field"   //大于关联参数
+	CodeExactlyOne = "exactly_one" //多个参数必须且只能填写一个
+	CodeFunc       = "func"        //自定义校验失败 expected为自定义函数返回的错误信息
+	CodeFileSize   = "file_size"   //文件大小超出
+	CodeFileExt    = "file_ext"    //文件扩展名不允许
+	CodeFileType   = "file_type"   //文件类型不允许
+)
+
+// 支持的语言
+const (
+	LangZh = "zh"
+	LangEn = "en"
+)
+
+// DefaultLang 默认语言 错误信息默认按该语言生成
+var DefaultLang = LangZh
+
+var (
+	msgLock sync.RWMutex
+	// 错误信息模板 {desc}为参数描述 {expected}为期望值 {other}为关联参数的描述
+	messages = map[string]map[string]string{
+		LangZh: {
+			CodeRequired:   "{desc}不能为空",
+			CodeType:       "{desc}类型错误",
+			CodeTooShort:   "{desc}最少{expected}个字",
+			CodeTooLong:    "{desc}最多{expected}个字",
+			CodeTooSmall:   "{desc}不能小于{expected}",
+			CodeTooLarge:   "{desc}不能大于{expected}",
+			CodeTooFew:     "{desc}最少{expected}项",
+			CodeTooMany:    "{desc}最多{expected}项",
+			CodePattern:    "{desc}正则校验失败",
+			CodeEnum:       "{desc}只能为{expected}",
+			CodeEmail:      "{desc}邮箱格式错误",
+			CodeMobile:     "{desc}手机号格式错误",
+			CodeURL:        "{desc}网址格式错误",
+			CodeIP:         "{desc}IP格式错误",
+			CodeDate:       "{desc}格式错误 应为{expected}",
+			CodePrecision:  "{desc}最多{expected}位小数",
+			CodeEqField:    "{desc}必须与{other}一致",
+			CodeNeField:    "{desc}不能与{other}相同",
+			CodeGtField:    "{desc}必须大于{other}",
+			CodeGteField:   "{desc}不能小于{other}",
+			CodeLtField:    "{desc}必须小于{other}",
+			CodeLteField:   "{desc}不能大于{other}",
+			CodeExactlyOne: "{desc}必须且只能填写一项",
+			CodeFunc:       "{desc}{expected}",
+			CodeFileSize:   "{desc}不能超过{expected}字节",
+			CodeFileExt:    "{desc}只能为{expected}格式",
+			CodeFileType:   "{desc}文件类型错误",
+		},
+		LangEn: {
+			CodeRequired:   "{desc} is required",
+			CodeType:       "{desc} has an invalid type",
+			CodeTooShort:   "{desc} must be at least {expected} characters",
+			CodeTooLong:    "{desc} must be at most {expected} characters",
+			CodeTooSmall:   "{desc} must not be less than {expected}",
+			CodeTooLarge:   "{desc} must not be greater than {expected}",
+			CodeTooFew:     "{desc} must contain at least {expected} items",
+			CodeTooMany:    "{desc} must contain at most {expected} items",
+			CodePattern:    "{desc} has an invalid format",
+			CodeEnum:       "{desc} must be one of {expected}",
+			CodeEmail:      "{desc} is not a valid email address",
+			CodeMobile:     "{desc} is not a valid mobile number",
+			CodeURL:        "{desc} is not a valid URL",
+			CodeIP:         "{desc} is not a valid IP address",
+			CodeDate:       "{desc} must be a date in the format {expected}",
+			CodePrecision:  "{desc} must have at most {expected} decimal places",
+			CodeEqField:    "{desc} must match {other}",
+			CodeNeField:    "{desc} must differ from {other}",
+			CodeGtField:    "{desc} must be greater than {other}",
+			CodeGteField:   "{desc} must not be less than {other}",
+			CodeLtField:    "{desc} must be less than {other}",
+			CodeLteField:   "{desc} must not be greater than {other}",
+			CodeExactlyOne: "exactly one of {desc} is required",
+			CodeFunc:       "{desc} {expected}",
+			CodeFileSize:   "{desc} must not exceed {expected} bytes",
+			CodeFileExt:    "{desc} must be one of {expected}",
+			CodeFileType:   "{desc} has an unsupported file type",
+		},
+	}
+)
+
+// SetMessage 设置错误信息模板 可追加新的语言
+// {desc}替换为参数描述 {expected}替换为期望值 {other}替换为关联参数的描述
+func SetMessage(lang, code, tpl string) {
+	msgLock.Lock()
+	defer msgLock.Unlock()
+
+	if messages[lang] == nil {
+		messages[lang] = make(map[string]string)
+	}
+	messages[lang][code] = tpl
+}
+
+// 按语言生成错误信息 语言或模板不存在时使用默认语言
+func (e *FieldError) message(lang string) string {
+	msgLock.RLock()
+	tpl, ok := messages[lang][e.Code]
+	if !ok {
+		tpl, ok = messages[DefaultLang][e.Code]
+	}
+	msgLock.RUnlock()
+
+	if !ok {
+		return e.desc + " " + e.Code
+	}
+
+	return strings.NewReplacer("{desc}", e.desc, "{expected}", e.Expected, "{other}", e.other).Replace(tpl)
+}
+
+// Lang 根据Accept-Language解析语言 按权重顺序返回第一个支持的语言 均不支持时返回DefaultLang
+//
+//	Lang("en-US,en;q=0.9,zh-CN;q=0.8") //en
+func Lang(acceptLanguage string) string {
+	best, bestQ := DefaultLang, -1.0
+
+	msgLock.RLock()
+	defer msgLock.RUnlock()
+
+	for _, v := range strings.Split(acceptLanguage, ",") {
+		tag, q, _ := strings.Cut(strings.TrimSpace(v), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		tag, _, _ = strings.Cut(tag, "-")
+
+		if _, ok := messages[tag]; !ok {
+			continue
+		}
+
+		weight := 1.0
+		if q = strings.TrimSpace(q); strings.HasPrefix(q, "q=") {
+			w, err := strconv.ParseFloat(q[2:], 64)
+			if err != nil {
+				continue
+			}
+			weight = w
+		}
+
+		//q=0表示不接受该语言
+		if weight <= 0 {
+			continue
+		}
+
+		//权重相同时 先出现的优先
+		if weight > bestQ {
+			best, bestQ = tag, weight
+		}
+	}
+
+	return best
+}
+
+// FieldError 单个参数的校验错误
+type FieldError struct {
+	Field    string `json:"field"`    //参数名称
+	Rule     string `json:"rule"`     //未通过的规则 如required min max reg
+	Expected string `json:"expected"` //期望值 如最小长度 取值范围
+	Code     string `json:"code"`     //错误码
+	Msg      string `json:"msg"`      //错误信息
+
+	desc  string //参数描述 用于重新生成错误信息
+	other string //关联参数的描述 跨参数校验时使用
+}
+
+func (e *FieldError) Error() string {
+	return e.Msg
+}
+
+// Localize 按语言重新生成错误信息
+func (e *FieldError) Localize(lang string) *FieldError {
+	ne := *e
+	ne.Msg = ne.message(lang)
+	return &ne
+}
+
+// ValidationErrors 多个参数的校验错误
+type ValidationErrors []*FieldError
+
+func (es ValidationErrors) Error() string {
+	msg := make([]string, 0, len(es))
+	for _, e := range es {
+		msg = append(msg, e.Error())
+	}
+
+	return strings.Join(msg, "\n")
+}
+
+// Localize 按语言重新生成全部错误信息
+func (es ValidationErrors) Localize(lang string) ValidationErrors {
+	ret := make(ValidationErrors, 0, len(es))
+	for _, e := range es {
+		ret = append(ret, e.Localize(lang))
+	}
+
+	return ret
+}
+
+// 生成校验错误
+func newFieldError(field, desc, rule, code, expected string) *FieldError {
+	e := &FieldError{
+		Field:    field,
+		Rule:     rule,
+		Expected: expected,
+		Code:     code,
+		desc:     desc,
+	}
+	e.Msg = e.message(DefaultLang)
+
+	return e
+}
+
+// 按规则生成校验错误
+func (r *Regulation) newError(rule, code, expected string) *FieldError {
+	return newFieldError(r.Name, r.Desc, rule, code, expected)
+}
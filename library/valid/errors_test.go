@@ -0,0 +1,141 @@
+package valid
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// 按单条规则校验 返回校验后的值和校验错误
+func checkOne(t *testing.T, jsonStr string, reg *Regulation) (any, *FieldError) {
+	t.Helper()
+
+	v, err := NewValid(nil, []byte(jsonStr))
+	if err != nil {
+		t.Fatalf("%s 解析失败: %v", jsonStr, err)
+	}
+
+	data, err := v.RegData([]*Regulation{reg})
+	if err == nil {
+		return data[reg.Name], nil
+	}
+
+	var es ValidationErrors
+	if !errors.As(err, &es) || len(es) != 1 {
+		t.Fatalf("%s 期望一个校验错误 实际 %v", reg.Name, err)
+	}
+
+	return nil, es[0]
+}
+
+func TestFieldError(t *testing.T) {
+	tests := []struct {
+		json     string
+		reg      *Regulation
+		rule     string
+		code     string
+		expected string
+		zh       string
+		en       string
+	}{
+		{`{}`, &Regulation{Name: "name", Desc: "姓名", CheckType: String, Required: true},
+			"required", CodeRequired, "", "姓名不能为空", "姓名 is required"},
+		{`{"name":"ab"}`, &Regulation{Name: "name", Desc: "姓名", CheckType: String, Min: 3},
+			"min", CodeTooShort, "3", "姓名最少3个字", "姓名 must be at least 3 characters"},
+		{`{"name":"abcd"}`, &Regulation{Name: "name", Desc: "姓名", CheckType: String, Max: 3},
+			"max", CodeTooLong, "3", "姓名最多3个字", "姓名 must be at most 3 characters"},
+		{`{"name":"Ab1"}`, &Regulation{Name: "name", Desc: "姓名", CheckType: String, Reg: "^[a-z]+$"},
+			"reg", CodePattern, "^[a-z]+$", "姓名正则校验失败", "姓名 has an invalid format"},
+		{`{"age":"x"}`, &Regulation{Name: "age", Desc: "年龄", CheckType: Int},
+			"type", CodeType, "", "年龄类型错误", "年龄 has an invalid type"},
+		{`{"age":{"a":1}}`, &Regulation{Name: "age", Desc: "年龄", CheckType: Int},
+			"type", CodeType, "", "年龄类型错误", "年龄 has an invalid type"},
+		{`{"age":200}`, &Regulation{Name: "age", Desc: "年龄", CheckType: Int, Max: 150},
+			"max", CodeTooLarge, "150", "年龄不能大于150", "年龄 must not be greater than 150"},
+		{`{"age":-1}`, &Regulation{Name: "age", Desc: "年龄", CheckType: Int},
+			"min", CodeTooSmall, "0", "年龄不能小于0", "年龄 must not be less than 0"},
+		{`{"ids":[1,2,3]}`, &Regulation{Name: "ids", Desc: "编号", CheckType: ArrayInt, Max: 2},
+			"max", CodeTooLarge, "2", "编号不能大于2", "编号 must not be greater than 2"},
+		{`{"user":{"name":""}}`, &Regulation{Name: "user.name", Desc: "用户名", CheckType: String, Required: true},
+			"required", CodeRequired, "", "用户名不能为空", "用户名 is required"},
+	}
+
+	for _, tt := range tests {
+		_, fe := checkOne(t, tt.json, tt.reg)
+		if fe == nil {
+			t.Errorf("%s %s 期望校验失败", tt.reg.Name, tt.json)
+			continue
+		}
+
+		if fe.Field != tt.reg.Name || fe.Rule != tt.rule || fe.Code != tt.code || fe.Expected != tt.expected {
+			t.Errorf("%s %s 错误 %+v 期望 rule=%s code=%s expected=%s", tt.reg.Name, tt.json, fe, tt.rule, tt.code, tt.expected)
+		}
+		if fe.Msg != tt.zh || fe.Error() != tt.zh {
+			t.Errorf("%s 中文信息 %q 期望 %q", tt.reg.Name, fe.Msg, tt.zh)
+		}
+		if en := fe.Localize(LangEn); en.Msg != tt.en {
+			t.Errorf("%s 英文信息 %q 期望 %q", tt.reg.Name, en.Msg, tt.en)
+		}
+		if fe.Msg != tt.zh {
+			t.Errorf("%s Localize修改了原错误", tt.reg.Name)
+		}
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	v, err := NewValid(nil, []byte(`{"age":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = v.RegData([]*Regulation{
+		{Name: "name", Desc: "姓名", CheckType: String, Required: true},
+		{Name: "age", Desc: "年龄", CheckType: Int},
+		{Name: "nick", Desc: "昵称", CheckType: String},
+	})
+
+	var es ValidationErrors
+	if !errors.As(err, &es) {
+		t.Fatalf("期望ValidationErrors 实际 %v", err)
+	}
+
+	codes := make([]string, 0, len(es))
+	for _, e := range es {
+		codes = append(codes, e.Field+":"+e.Code)
+	}
+	if want := []string{"name:required", "age:type"}; !slices.Equal(codes, want) {
+		t.Errorf("校验错误 %v 期望 %v", codes, want)
+	}
+
+	if msg := es.Error(); msg != "姓名不能为空\n年龄类型错误" {
+		t.Errorf("错误信息 %q", msg)
+	}
+	if msg := es.Localize(LangEn).Error(); msg != "姓名 is required\n年龄 has an invalid type" {
+		t.Errorf("英文错误信息 %q", msg)
+	}
+}
+
+func TestLang(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", LangZh},
+		{"en", LangEn},
+		{"en-US,en;q=0.9,zh-CN;q=0.8", LangEn},
+		{"zh-CN,zh;q=0.9,en;q=0.8", LangZh},
+		{"zh;q=0.5,en;q=0.8", LangEn},
+		{"fr-FR,fr;q=0.9", LangZh},
+		{"fr,en;q=0.1", LangEn},
+		{"en;q=x,zh;q=0.2", LangZh},
+		{"en;q=0", LangZh},
+		{"en;q=0.0,fr", LangZh},
+		{"en;q=0,zh", LangZh},
+	}
+
+	for _, tt := range tests {
+		if got := Lang(tt.accept); got != tt.want {
+			t.Errorf("Lang(%q) = %s 期望 %s", tt.accept, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package valid
+
+import (
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 上传文件校验 大小 扩展名 内容类型
+// 内容类型由文件内容检测 不信任客户端提交的Content-Type
+// 注意xlsx docx等文件检测结果为application/zip xls为application/octet-stream
+
+// FileRule 上传文件校验规则
+type FileRule struct {
+	Name     string   //参数名称
+	Desc     string   //参数描述
+	Required bool     //是否必须上传
+	MaxSize  int64    //单个文件最大字节数 0为不限制
+	MaxCount int      //最多文件个数 0为不限制
+	Exts     []string //允许的扩展名 如.jpg .png 不区分大小写 为空不限制
+	Mimes    []string //允许的内容类型 以/结尾时按前缀匹配 如image/ 为空不限制
+}
+
+func (r *FileRule) newError(rule, code, expected string) *FieldError {
+	return newFieldError(r.Name, r.Desc, rule, code, expected)
+}
+
+// CheckFiles 按规则校验上传的文件 fhs为同名的全部文件
+func CheckFiles(fhs []*multipart.FileHeader, rule *FileRule) error {
+	if len(fhs) == 0 {
+		if rule.Required {
+			return rule.newError("required", CodeRequired, "")
+		}
+		return nil
+	}
+
+	if rule.MaxCount > 0 && len(fhs) > rule.MaxCount {
+		return rule.newError("max", CodeTooMany, strconv.Itoa(rule.MaxCount))
+	}
+
+	for _, fh := range fhs {
+		if err := CheckFile(fh, rule); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckFile 按规则校验单个上传文件
+func CheckFile(fh *multipart.FileHeader, rule *FileRule) error {
+	if fh == nil {
+		if rule.Required {
+			return rule.newError("required", CodeRequired, "")
+		}
+		return nil
+	}
+
+	if rule.MaxSize > 0 && fh.Size > rule.MaxSize {
+		return rule.newError("maxSize", CodeFileSize, strconv.FormatInt(rule.MaxSize, 10))
+	}
+
+	if len(rule.Exts) > 0 {
+		ext := strings.ToLower(filepath.Ext(fh.Filename))
+		if !slices.ContainsFunc(rule.Exts, func(v string) bool { return strings.ToLower(v) == ext }) {
+			return rule.newError("exts", CodeFileExt, strings.Join(rule.Exts, ","))
+		}
+	}
+
+	if len(rule.Mimes) > 0 {
+		mime, err := DetectFileType(fh)
+		if err != nil {
+			return err
+		}
+
+		if !slices.ContainsFunc(rule.Mimes, func(v string) bool {
+			if strings.HasSuffix(v, "/") {
+				return strings.HasPrefix(mime, v)
+			}
+			return mime == v
+		}) {
+			return rule.newError("mimes", CodeFileType, strings.Join(rule.Mimes, ","))
+		}
+	}
+
+	return nil
+}
+
+// DetectFileType 根据文件内容检测文件类型 不含charset等参数
+func DetectFileType(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	mime, _, _ := strings.Cut(http.DetectContentType(buf[:n]), ";")
+
+	return mime, nil
+}
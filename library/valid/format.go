@@ -0,0 +1,102 @@
+package valid
+
+import (
+	"github.com/solaa51/swagger/cFunc"
+	"net"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// 常用格式校验 取值范围 日期 邮箱 网址 IP 手机号
+// 参数为空且非必填时不校验格式
+
+// GetEnum 获取字符串 并校验是否在Enum范围内
+func (v *Valid) GetEnum(reg *Regulation) (bool, string, error) {
+	exist, value, err := v.GetString(reg)
+	if err != nil {
+		return exist, "", err
+	}
+
+	if value != "" && !slices.Contains(reg.Enum, value) {
+		return exist, "", reg.newError("enum", CodeEnum, strings.Join(reg.Enum, ","))
+	}
+
+	return exist, value, nil
+}
+
+// GetDate 获取日期并转换为时间戳 格式由Layout指定 未指定时Date为Y-m-d DateTime为Y-m-d H:i:s
+func (v *Valid) GetDate(reg *Regulation) (bool, int64, error) {
+	exist, value, err := v.GetString(reg)
+	if err != nil {
+		return exist, 0, err
+	}
+
+	if value == "" {
+		return exist, 0, nil
+	}
+
+	layout := reg.Layout
+	if layout == "" {
+		layout = "Y-m-d"
+		if reg.CheckType == DateTime {
+			layout = "Y-m-d H:i:s"
+		}
+	}
+
+	stamp, err := cFunc.StrToTime(layout, value)
+	if err != nil {
+		return exist, 0, reg.newError("layout", CodeDate, layout)
+	}
+
+	return exist, stamp, nil
+}
+
+// GetEmail 获取邮箱
+func (v *Valid) GetEmail(reg *Regulation) (bool, string, error) {
+	return v.getFormat(reg, "email", CodeEmail, isEmail)
+}
+
+// GetURL 获取网址 仅支持http https
+func (v *Valid) GetURL(reg *Regulation) (bool, string, error) {
+	return v.getFormat(reg, "url", CodeURL, isURL)
+}
+
+// GetIP 获取IP 支持IPv4 IPv6
+func (v *Valid) GetIP(reg *Regulation) (bool, string, error) {
+	return v.getFormat(reg, "ip", CodeIP, isIP)
+}
+
+// GetMobile 获取手机号
+func (v *Valid) GetMobile(reg *Regulation) (bool, string, error) {
+	return v.getFormat(reg, "mobile", CodeMobile, cFunc.CheckMobile)
+}
+
+// 获取字符串并校验格式
+func (v *Valid) getFormat(reg *Regulation, rule, code string, check func(string) bool) (bool, string, error) {
+	exist, value, err := v.GetString(reg)
+	if err != nil {
+		return exist, "", err
+	}
+
+	if value != "" && !check(value) {
+		return exist, "", reg.newError(rule, code, "")
+	}
+
+	return exist, value, nil
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+func isURL(s string) bool {
+	u, err := url.ParseRequestURI(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func isIP(s string) bool {
+	return net.ParseIP(s) != nil
+}
@@ -0,0 +1,66 @@
+package valid
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		json string
+		reg  *Regulation
+		want any    //校验通过时的值
+		code string //校验失败时的错误码
+	}{
+		{`{"f":"1.25"}`, &Regulation{Name: "f", CheckType: Float, MaxFloat: 2}, 1.25, ""},
+		{`{"f":2.5}`, &Regulation{Name: "f", CheckType: Float, MaxFloat: 2}, nil, CodeTooLarge},
+		{`{"f":"1.255"}`, &Regulation{Name: "f", CheckType: Float, Precision: 2}, nil, CodePrecision},
+		{`{"f":"abc"}`, &Regulation{Name: "f", CheckType: Float}, nil, CodeType},
+		{`{"b":true}`, &Regulation{Name: "b", CheckType: Bool}, true, ""},
+		{`{"b":"off"}`, &Regulation{Name: "b", CheckType: Bool}, false, ""},
+		{`{"b":"maybe"}`, &Regulation{Name: "b", CheckType: Bool}, nil, CodeType},
+		{`{"s":"paid"}`, &Regulation{Name: "s", CheckType: Enum, Enum: []string{"new", "paid"}}, "paid", ""},
+		{`{"s":"lost"}`, &Regulation{Name: "s", CheckType: Enum, Enum: []string{"new", "paid"}}, nil, CodeEnum},
+		{`{"d":"2024-02-30"}`, &Regulation{Name: "d", CheckType: Date}, nil, CodeDate},
+		{`{"d":"2024/01/02"}`, &Regulation{Name: "d", CheckType: Date}, nil, CodeDate},
+		{`{"d":"2024-01-02 10:00"}`, &Regulation{Name: "d", CheckType: DateTime}, nil, CodeDate},
+		{`{"e":"a@b.com"}`, &Regulation{Name: "e", CheckType: Email}, "a@b.com", ""},
+		{`{"e":"Tom <a@b.com>"}`, &Regulation{Name: "e", CheckType: Email}, nil, CodeEmail},
+		{`{"e":"a.b.com"}`, &Regulation{Name: "e", CheckType: Email}, nil, CodeEmail},
+		{`{"u":"https://a.com/x?y=1"}`, &Regulation{Name: "u", CheckType: URL}, "https://a.com/x?y=1", ""},
+		{`{"u":"ftp://a.com"}`, &Regulation{Name: "u", CheckType: URL}, nil, CodeURL},
+		{`{"u":"a.com"}`, &Regulation{Name: "u", CheckType: URL}, nil, CodeURL},
+		{`{"ip":"10.0.0.1"}`, &Regulation{Name: "ip", CheckType: IP}, "10.0.0.1", ""},
+		{`{"ip":"::1"}`, &Regulation{Name: "ip", CheckType: IP}, "::1", ""},
+		{`{"ip":"10.0.0.256"}`, &Regulation{Name: "ip", CheckType: IP}, nil, CodeIP},
+		{`{"m":"13800138000"}`, &Regulation{Name: "m", CheckType: Mobile}, "13800138000", ""},
+		{`{"m":"1380013800"}`, &Regulation{Name: "m", CheckType: Mobile}, nil, CodeMobile},
+		{`{}`, &Regulation{Name: "m", CheckType: Mobile}, "", ""},
+		{`{}`, &Regulation{Name: "e", CheckType: Email, Required: true}, nil, CodeRequired},
+	}
+
+	for _, tt := range tests {
+		value, fe := checkOne(t, tt.json, tt.reg)
+		if tt.code != "" {
+			if fe == nil || fe.Code != tt.code {
+				t.Errorf("%s 期望错误码 %s 实际 %v %v", tt.json, tt.code, value, fe)
+			}
+			continue
+		}
+
+		if fe != nil {
+			t.Errorf("%s 校验失败: %v", tt.json, fe)
+			continue
+		}
+		if value != tt.want {
+			t.Errorf("%s = %v(%T) 期望 %v(%T)", tt.json, value, value, tt.want, tt.want)
+		}
+	}
+
+	//日期转为时间戳 可按大小比较
+	v1, fe1 := checkOne(t, `{"d":"2024-01-02"}`, &Regulation{Name: "d", CheckType: Date})
+	v2, fe2 := checkOne(t, `{"d":"2024-01-03 00:00:00"}`, &Regulation{Name: "d", CheckType: DateTime})
+	if fe1 != nil || fe2 != nil {
+		t.Fatalf("日期校验失败 %v %v", fe1, fe2)
+	}
+	if v2.(int64)-v1.(int64) != 86400 {
+		t.Errorf("日期时间戳 %v %v", v1, v2)
+	}
+}
@@ -0,0 +1,156 @@
+package valid
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// json数据树 对象为map[string]any 数组为[]any 数字为json.Number
+// 通过路径读取 如address.city items[0].sku 顶层须为json对象
+
+// 解析json对象 数字保留原始文本 避免大整数丢失精度
+func parseJsonTree(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var tree map[string]any
+	if err := dec.Decode(&tree); err != nil || tree == nil {
+		return nil, errors.New("json格式错误")
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("json格式错误")
+	}
+
+	return tree, nil
+}
+
+// 路径中的一段 对象键或数组下标
+type pathKey struct {
+	name  string
+	index int
+	isArr bool
+}
+
+// 拆分路径 items[0].sku => items [0] sku
+func splitPath(path string) ([]pathKey, error) {
+	keys := make([]pathKey, 0, 4)
+	for path != "" {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				return nil, errors.New("路径格式错误")
+			}
+
+			i, err := strconv.Atoi(path[1:end])
+			if err != nil || i < 0 {
+				return nil, errors.New("路径格式错误")
+			}
+			keys = append(keys, pathKey{index: i, isArr: true})
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			keys = append(keys, pathKey{name: path[:end]})
+			path = path[end:]
+		}
+	}
+
+	return keys, nil
+}
+
+// 按路径查找数据 优先按完整名称查找 兼容名称中包含.的参数
+func (v *Valid) lookup(path string) (any, bool) {
+	if val, ok := v.data[path]; ok {
+		return val, true
+	}
+
+	keys, err := splitPath(path)
+	if err != nil || len(keys) == 0 {
+		return nil, false
+	}
+
+	var cur any = v.data
+	for _, k := range keys {
+		switch node := cur.(type) {
+		case map[string]any:
+			if k.isArr {
+				return nil, false
+			}
+			val, ok := node[k.name]
+			if !ok {
+				return nil, false
+			}
+			cur = val
+		case []any:
+			if !k.isArr || k.index >= len(node) {
+				return nil, false
+			}
+			cur = node[k.index]
+		default:
+			return nil, false
+		}
+	}
+
+	return cur, true
+}
+
+// 标量转字符串 对象和数组返回false
+func scalarString(val any) (string, bool) {
+	switch t := val.(type) {
+	case nil:
+		return "", true
+	case string:
+		return t, true
+	case json.Number:
+		return t.String(), true
+	case bool:
+		if t {
+			return "true", true
+		}
+		return "false", true
+	}
+
+	return "", false
+}
+
+// 转为兼容旧版MapData的字符串 数组以,连接 对象为json字符串
+func flatString(val any) string {
+	if s, ok := scalarString(val); ok {
+		return s
+	}
+
+	if arr, ok := val.([]any); ok {
+		ss := make([]string, 0, len(arr))
+		for _, e := range arr {
+			ss = append(ss, flatString(e))
+		}
+		return strings.Join(ss, ",")
+	}
+
+	b, _ := json.Marshal(val)
+	return string(b)
+}
+
+// 通过数据树创建校验对象
+func newTreeValid(tree map[string]any) *Valid {
+	v := &Valid{
+		MapData: make(map[string]string, len(tree)),
+		data:    tree,
+	}
+
+	for k, val := range tree {
+		v.MapData[k] = flatString(val)
+	}
+
+	return v
+}
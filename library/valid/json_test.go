@@ -0,0 +1,88 @@
+package valid
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookupPath(t *testing.T) {
+	const data = `{"address":{"city":"bj","geo":{"lat":"39.9"}},"items":[{"sku":"a1"},{"sku":"b2"}],"a.b":"dot","big":12345678901234567890,"tags":["x","y"]}`
+
+	tests := []struct {
+		name  string
+		want  string
+		exist bool
+	}{
+		{"address.city", "bj", true},
+		{"address.geo.lat", "39.9", true},
+		{"items[0].sku", "a1", true},
+		{"items[1].sku", "b2", true},
+		{"items[2].sku", "", false},
+		{"items.sku", "", false},
+		{"address[0]", "", false},
+		{"tags[1]", "y", true},
+		{"a.b", "dot", true},
+		{"big", "12345678901234567890", true},
+		{"address.none", "", false},
+		{"items[x]", "", false},
+	}
+
+	v, err := NewValid(nil, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		exist, value, err := v.GetString(&Regulation{Name: tt.name})
+		if err != nil {
+			t.Errorf("%s 校验失败: %v", tt.name, err)
+			continue
+		}
+		if exist != tt.exist || value != tt.want {
+			t.Errorf("%s = %q %v 期望 %q %v", tt.name, value, exist, tt.want, tt.exist)
+		}
+	}
+
+	if v.MapData["tags"] != "x,y" || v.MapData["a.b"] != "dot" {
+		t.Errorf("MapData %v", v.MapData)
+	}
+}
+
+func TestObjectArray(t *testing.T) {
+	v, err := NewValid(nil, []byte(`{"items":[{"sku":"a1","qty":2},{"sku":"","qty":0}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, items, err := v.GetObjectArray(&Regulation{Name: "items", Desc: "商品", Min: 1, Max: 5})
+	if err != nil || len(items) != 2 {
+		t.Fatalf("items %v %v", items, err)
+	}
+
+	regs := []*Regulation{
+		{Name: "sku", Desc: "商品编号", CheckType: String, Required: true},
+		{Name: "qty", Desc: "数量", CheckType: Int, Min: 1},
+	}
+	if _, err = items[0].RegData(regs); err != nil {
+		t.Errorf("items[0] 校验失败: %v", err)
+	}
+
+	_, err = items[1].RegData(regs)
+	var es ValidationErrors
+	if !errors.As(err, &es) || len(es) != 2 {
+		t.Fatalf("items[1] 期望两个校验错误 实际 %v", err)
+	}
+
+	_, _, err = v.GetObjectArray(&Regulation{Name: "items", Desc: "商品", Max: 1})
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Code != CodeTooMany {
+		t.Errorf("元素个数超出 期望 %s 实际 %v", CodeTooMany, err)
+	}
+}
+
+// 顶层须为json对象
+func TestTopLevelArray(t *testing.T) {
+	if _, err := NewValid(nil, []byte(`[{"name":"a"}]`)); err == nil {
+		t.Error("顶层为数组时应返回错误")
+	}
+}
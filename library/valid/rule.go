@@ -0,0 +1,265 @@
+package valid
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// 跨参数校验和自定义校验
+//
+//	data, err := v.RegData([]*valid.Regulation{
+//		{Name: "start_time", Desc: "开始时间", CheckType: valid.DateTime, Required: true},
+//		{Name: "end_time", Desc: "结束时间", CheckType: valid.DateTime, Required: true},
+//		{Name: "invoice_title", Desc: "发票抬头", CheckType: valid.String},
+//		{Name: "username", Desc: "用户名", CheckType: valid.String, Func: "unique_username"},
+//	},
+//		valid.Compare("end_time", ">", "start_time"),
+//		valid.RequiredIf("invoice_title", "need_invoice", "1"),
+//		valid.ExactlyOneOf("phone", "email"),
+//	)
+
+// ValidFunc 自定义校验函数 value为参数校验后的值 返回的错误信息追加在参数描述之后
+type ValidFunc func(value any, v *Valid) error
+
+var (
+	funcLock sync.RWMutex
+	funcs    = make(map[string]ValidFunc)
+)
+
+// RegisterFunc 注册自定义校验函数 通过Regulation.Func按名称使用 同名覆盖
+func RegisterFunc(name string, f ValidFunc) {
+	funcLock.Lock()
+	defer funcLock.Unlock()
+
+	funcs[name] = f
+}
+
+// 执行参数的自定义校验函数
+func (r *Regulation) callFunc(value any, v *Valid) error {
+	if r.Func == "" {
+		return nil
+	}
+
+	funcLock.RLock()
+	f, ok := funcs[r.Func]
+	funcLock.RUnlock()
+
+	if !ok {
+		return errors.New("未注册的校验函数:" + r.Func)
+	}
+
+	if err := f(value, v); err != nil {
+		var fe *FieldError
+		if errors.As(err, &fe) {
+			return fe
+		}
+		return r.newError(r.Func, CodeFunc, err.Error())
+	}
+
+	return nil
+}
+
+// RuleData 跨参数校验时可用的数据
+type RuleData struct {
+	Valid *Valid         //原始参数
+	Data  map[string]any //单个参数校验通过后的值
+
+	regs   map[string]*Regulation
+	failed map[string]bool
+}
+
+// Desc 获取参数描述 未设置规则时为参数名称
+func (d *RuleData) Desc(name string) string {
+	if reg, ok := d.regs[name]; ok && reg.Desc != "" {
+		return reg.Desc
+	}
+
+	return name
+}
+
+// Exist 参数是否存在且不为空
+func (d *RuleData) Exist(name string) bool {
+	val, ok := d.Valid.lookup(name)
+	if !ok || val == nil {
+		return false
+	}
+
+	switch t := val.(type) {
+	case string:
+		return t != ""
+	case []any:
+		return len(t) > 0
+	}
+
+	return true
+}
+
+// Failed 参数是否未通过单个参数校验
+func (d *RuleData) Failed(name string) bool {
+	return d.failed[name]
+}
+
+// NewError 生成跨参数校验错误 field和other为参数名称 自动转为参数描述
+func (d *RuleData) NewError(field, rule, code, expected, other string) *FieldError {
+	e := &FieldError{
+		Field:    field,
+		Rule:     rule,
+		Expected: expected,
+		Code:     code,
+		desc:     d.Desc(field),
+		other:    d.Desc(other),
+	}
+	e.Msg = e.message(DefaultLang)
+
+	return e
+}
+
+// Rule 跨参数校验规则 在单个参数校验完成后依次执行
+type Rule interface {
+	Check(d *RuleData) *FieldError
+}
+
+// RuleFunc 函数形式的跨参数校验规则
+type RuleFunc func(d *RuleData) *FieldError
+
+func (f RuleFunc) Check(d *RuleData) *FieldError {
+	return f(d)
+}
+
+// 比较运算符对应的错误码
+var compareCodes = map[string]string{
+	"==": CodeEqField,
+	"!=": CodeNeField,
+	">":  CodeGtField,
+	">=": CodeGteField,
+	"<":  CodeLtField,
+	"<=": CodeLteField,
+}
+
+// Compare 比较两个参数校验后的值 op支持 == != > >= < <=
+// 数字按大小比较 日期类型为时间戳 其他按字符串比较 任一参数不存在或未通过校验时跳过
+//
+//	valid.Compare("end_time", ">", "start_time")
+//	valid.Compare("password_confirm", "==", "password")
+func Compare(field, op, other string) Rule {
+	code, ok := compareCodes[op]
+	if !ok {
+		panic("valid.Compare不支持的运算符:" + op)
+	}
+
+	return RuleFunc(func(d *RuleData) *FieldError {
+		if d.Failed(field) || d.Failed(other) || !d.Exist(field) || !d.Exist(other) {
+			return nil
+		}
+
+		a, b := d.Data[field], d.Data[other]
+		if !compareValue(a, b, op) {
+			return d.NewError(field, "compare", code, other, other)
+		}
+
+		return nil
+	})
+}
+
+func compareValue(a, b any, op string) bool {
+	var c int
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if okA && okB {
+		switch {
+		case fa < fb:
+			c = -1
+		case fa > fb:
+			c = 1
+		}
+	} else {
+		c = strings.Compare(flatString(a), flatString(b))
+	}
+
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case int64:
+		return float64(t), true
+	case float64:
+		return t, true
+	}
+
+	return 0, false
+}
+
+// RequiredIf 当other参数的值为values中的任意一个时 field参数必填
+//
+//	valid.RequiredIf("invoice_title", "need_invoice", "1")
+func RequiredIf(field, other string, values ...string) Rule {
+	return RuleFunc(func(d *RuleData) *FieldError {
+		if d.Failed(field) || d.Exist(field) {
+			return nil
+		}
+
+		val, ok := d.Valid.lookup(other)
+		if !ok {
+			return nil
+		}
+
+		s, ok := scalarString(val)
+		if !ok {
+			return nil
+		}
+
+		for _, v := range values {
+			if s == v {
+				return d.NewError(field, "required_if", CodeRequired, other+"="+strings.Join(values, "|"), other)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ExactlyOneOf 多个参数必须且只能填写一个
+//
+//	valid.ExactlyOneOf("phone", "email")
+func ExactlyOneOf(fields ...string) Rule {
+	return RuleFunc(func(d *RuleData) *FieldError {
+		n := 0
+		for _, f := range fields {
+			if d.Exist(f) {
+				n++
+			}
+		}
+
+		if n == 1 || len(fields) == 0 {
+			return nil
+		}
+
+		descs := make([]string, 0, len(fields))
+		for _, f := range fields {
+			descs = append(descs, d.Desc(f))
+		}
+
+		e := d.NewError(fields[0], "exactly_one_of", CodeExactlyOne, strings.Join(fields, ","), "")
+		e.desc = strings.Join(descs, ",")
+		e.Msg = e.message(DefaultLang)
+
+		return e
+	})
+}
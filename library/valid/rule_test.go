@@ -0,0 +1,87 @@
+package valid
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCrossField(t *testing.T) {
+	RegisterFunc("test_not_admin", func(value any, v *Valid) error {
+		if value == "admin" {
+			return errors.New("已被占用")
+		}
+		return nil
+	})
+
+	regs := []*Regulation{
+		{Name: "start", Desc: "开始时间", CheckType: DateTime},
+		{Name: "end", Desc: "结束时间", CheckType: DateTime},
+		{Name: "password", Desc: "密码", CheckType: String},
+		{Name: "confirm", Desc: "确认密码", CheckType: String},
+		{Name: "need_invoice", Desc: "是否开票", CheckType: Int},
+		{Name: "title", Desc: "发票抬头", CheckType: String},
+		{Name: "phone", Desc: "手机号", CheckType: String},
+		{Name: "email", Desc: "邮箱", CheckType: String},
+		{Name: "username", Desc: "用户名", CheckType: String, Func: "test_not_admin"},
+	}
+	rules := []Rule{
+		Compare("end", ">", "start"),
+		Compare("confirm", "==", "password"),
+		RequiredIf("title", "need_invoice", "1"),
+		ExactlyOneOf("phone", "email"),
+	}
+
+	tests := []struct {
+		json string
+		want []string //字段:错误码
+		msg  string   //第一个错误的中文信息
+	}{
+		{`{"start":"2024-01-01 10:00:00","end":"2024-01-01 12:00:00","password":"a","confirm":"a","phone":"1"}`, nil, ""},
+		{`{"start":"2024-01-01 10:00:00","end":"2024-01-01 09:00:00","phone":"1"}`, []string{"end:" + CodeGtField}, "结束时间必须大于开始时间"},
+		{`{"start":"bad","end":"2024-01-01 09:00:00","phone":"1"}`, []string{"start:" + CodeDate}, ""},
+		{`{"password":"a","confirm":"b","phone":"1"}`, []string{"confirm:" + CodeEqField}, "确认密码必须与密码一致"},
+		{`{"need_invoice":1,"phone":"1"}`, []string{"title:" + CodeRequired}, "发票抬头不能为空"},
+		{`{"need_invoice":0,"phone":"1"}`, nil, ""},
+		{`{}`, []string{"phone:" + CodeExactlyOne}, "手机号,邮箱必须且只能填写一项"},
+		{`{"phone":"1","email":"a@b.com"}`, []string{"phone:" + CodeExactlyOne}, ""},
+		{`{"phone":"1","username":"admin"}`, []string{"username:" + CodeFunc}, "用户名已被占用"},
+	}
+
+	for _, tt := range tests {
+		v, err := NewValid(nil, []byte(tt.json))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = v.RegData(regs, rules...)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s 校验失败: %v", tt.json, err)
+			}
+			continue
+		}
+
+		var es ValidationErrors
+		if !errors.As(err, &es) {
+			t.Errorf("%s 期望校验错误 实际 %v", tt.json, err)
+			continue
+		}
+
+		got := make([]string, 0, len(es))
+		for _, e := range es {
+			got = append(got, e.Field+":"+e.Code)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s 校验错误 %v 期望 %v", tt.json, got, tt.want)
+		}
+		if tt.msg != "" && es[0].Msg != tt.msg {
+			t.Errorf("%s 错误信息 %q 期望 %q", tt.json, es[0].Msg, tt.msg)
+		}
+	}
+
+	v, _ := NewValid(nil, []byte(`{"username":"a"}`))
+	if _, err := v.RegData([]*Regulation{{Name: "username", CheckType: String, Func: "test_undefined"}}); err == nil || errors.As(err, new(ValidationErrors)) {
+		t.Errorf("未注册的校验函数应返回普通错误 实际 %v", err)
+	}
+}
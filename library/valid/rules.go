@@ -0,0 +1,90 @@
+package valid
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// 预编译的校验规则 在初始化时创建并复用 正则规则只编译一次 规则错误在启动时暴露
+//
+//	var loginRules = valid.MustRules([]*valid.Regulation{
+//		{Name: "username", Desc: "用户名", CheckType: valid.String, Required: true, Reg: "^[a-zA-Z][a-zA-Z0-9]{4,31}$"},
+//	})
+//
+//	data, err := ctx.CheckRules(loginRules)
+
+// 正则缓存 未预编译的规则按正则字符串复用
+var regCache sync.Map
+
+func compileReg(pattern string) (*regexp.Regexp, error) {
+	if rc, ok := regCache.Load(pattern); ok {
+		return rc.(*regexp.Regexp), nil
+	}
+
+	rc, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regCache.Store(pattern, rc)
+
+	return rc, nil
+}
+
+// Rules 预编译的校验规则 创建后不可修改 可并发使用
+type Rules struct {
+	regs  []*Regulation
+	rules []Rule
+}
+
+// NewRules 检查并预编译校验规则 规则错误时返回error
+// regs会被复制 创建后修改原规则不影响Rules
+func NewRules(regs []*Regulation, rules ...Rule) (*Rules, error) {
+	rs := &Rules{
+		regs:  make([]*Regulation, 0, len(regs)),
+		rules: rules,
+	}
+
+	for i, reg := range regs {
+		if reg == nil || reg.Name == "" {
+			return nil, errors.New("校验规则第" + strconv.Itoa(i+1) + "项缺少参数名称")
+		}
+
+		if reg.CheckType < Int || reg.CheckType > Mobile {
+			return nil, errors.New("不支持的校验类型:" + reg.Name)
+		}
+
+		if reg.CheckType == Enum && len(reg.Enum) == 0 {
+			return nil, errors.New("缺少取值范围:" + reg.Name)
+		}
+
+		nr := *reg
+		if nr.Reg != "" {
+			rc, err := compileReg(nr.Reg)
+			if err != nil {
+				return nil, errors.New("正则规则错误:" + reg.Name + " " + err.Error())
+			}
+			nr.rc = rc
+		}
+
+		rs.regs = append(rs.regs, &nr)
+	}
+
+	return rs, nil
+}
+
+// MustRules 同NewRules 规则错误时panic 用于包级变量初始化
+func MustRules(regs []*Regulation, rules ...Rule) *Rules {
+	rs, err := NewRules(regs, rules...)
+	if err != nil {
+		panic(err)
+	}
+
+	return rs
+}
+
+// Check 按预编译的规则校验参数 同RegData
+func (rs *Rules) Check(v *Valid) (map[string]any, error) {
+	return v.RegData(rs.regs, rs.rules...)
+}
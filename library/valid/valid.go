@@ -2,7 +2,6 @@ package valid
 
 import (
 	"errors"
-	"github.com/solaa51/swagger/cFunc"
 	"maps"
 	"net/url"
 	"regexp"
@@ -19,56 +18,87 @@ const (
 	String
 	ArrayInt    //仅支持在json的内层使用
 	ArrayString //仅支持在json的内层使用
+	Bool
+	Float
+	Object      //json对象 返回*Valid
+	ArrayObject //json对象数组 返回[]*Valid
+	Enum        //取值范围 由Enum指定
+	Date        //日期 返回时间戳 格式由Layout指定 默认Y-m-d
+	DateTime    //日期时间 返回时间戳 格式由Layout指定 默认Y-m-d H:i:s
+	Email
+	URL
+	IP
+	Mobile
 )
 
 // Regulation 校验规则
+// Name 支持路径 如address.city items[0].sku
 type Regulation struct {
 	Def       any       //默认值
 	Name      string    //参数名称
 	Desc      string    //参数描述
 	Reg       string    //正则规则校验
-	Min       int64     //最小值或最小长度
-	Max       int64     //最大值或最大长度
+	Min       int64     //最小值或最小长度 对象数组为最少元素个数
+	Max       int64     //最大值或最大长度 对象数组为最多元素个数
 	CheckType validType //校验类型
 	Required  bool      //是否必填
+
+	MinFloat  float64  //小数最小值
+	MaxFloat  float64  //小数最大值 0为不限制
+	Precision int      //小数最多位数 0为不限制
+	Enum      []string //取值范围
+	Layout    string   //日期格式 同cFunc.Date 如Y-m-d H:i:s
+	Func      string   //自定义校验函数名称 通过RegisterFunc注册
+
+	rc *regexp.Regexp //预编译的正则 由NewRules设置
 }
 
 type Valid struct {
-	MapData map[string]string //解析后的键值对
+	MapData map[string]string //解析后的第一层键值对 数组以,连接 对象为json字符串
+	data    map[string]any    //解析后的数据树
 }
 
 // NewValid 创建校验对象
 // url.Values url参数 或 post 键值对 参数只解析[0] 【数组不做处理】
 // jsonStr json字符串 当key相同时 json权重高于url.Values数据
 func NewValid(urlValues url.Values, jsonStr []byte) (*Valid, error) {
-	v := &Valid{
-		MapData: make(map[string]string),
-	}
+	tree := make(map[string]any)
 
-	if urlValues != nil {
-		data := v.parseParamHttpFields(urlValues)
-		maps.Copy(v.MapData, data)
+	for k, v := range urlValues {
+		if len(v) > 0 {
+			tree[k] = v[0]
+		}
 	}
 
 	if jsonStr != nil {
-		data, err := v.parseJson(jsonStr)
+		data, err := parseJsonTree(jsonStr)
 		if err != nil {
 			return nil, err
 		}
-		maps.Copy(v.MapData, data)
+		maps.Copy(tree, data)
 	}
 
-	return v, nil
+	return newTreeValid(tree), nil
 }
 
-// RegData 根据规则校验参数 碰到错误立即返回
-func (v *Valid) RegData(regs []*Regulation) (map[string]any, error) {
+// RegData 根据规则校验全部参数 单个参数校验完成后 依次执行跨参数校验规则rules
+// 校验失败时返回ValidationErrors 包含全部参数的错误
+func (v *Valid) RegData(regs []*Regulation, rules ...Rule) (map[string]any, error) {
 	ret := make(map[string]any, len(regs))
+	errs := make(ValidationErrors, 0)
+	rd := &RuleData{
+		Valid:  v,
+		Data:   ret,
+		regs:   make(map[string]*Regulation, len(regs)),
+		failed: make(map[string]bool),
+	}
 
 	var value any
 	var err error
 
 	for _, reg := range regs {
+		rd.regs[reg.Name] = reg
+
 		switch reg.CheckType {
 		case Int:
 			_, value, err = v.GetInt64(reg)
@@ -78,201 +108,322 @@ func (v *Valid) RegData(regs []*Regulation) (map[string]any, error) {
 			_, value, err = v.GetIntArray(reg)
 		case ArrayString:
 			_, value, err = v.GetStringArray(reg)
+		case Bool:
+			_, value, err = v.GetBool(reg)
+		case Float:
+			_, value, err = v.GetFloat64(reg)
+		case Object:
+			_, value, err = v.GetObject(reg)
+		case ArrayObject:
+			_, value, err = v.GetObjectArray(reg)
+		case Enum:
+			_, value, err = v.GetEnum(reg)
+		case Date, DateTime:
+			_, value, err = v.GetDate(reg)
+		case Email:
+			_, value, err = v.GetEmail(reg)
+		case URL:
+			_, value, err = v.GetURL(reg)
+		case IP:
+			_, value, err = v.GetIP(reg)
+		case Mobile:
+			_, value, err = v.GetMobile(reg)
 		default:
 			return nil, errors.New("不支持的校验类型")
 		}
 
+		if err == nil {
+			err = reg.callFunc(value, v)
+		}
+
 		if err != nil {
-			return nil, err
+			var fe *FieldError
+			if !errors.As(err, &fe) {
+				return nil, err
+			}
+			errs = append(errs, fe)
+			rd.failed[reg.Name] = true
+			continue
 		}
 
 		ret[reg.Name] = value
 	}
 
-	return ret, nil
-}
-
-func (v *Valid) parseJson(data []byte) (map[string]string, error) {
-	return cFunc.ParseSimpleJson(&data)
-}
+	for _, rule := range rules {
+		if fe := rule.Check(rd); fe != nil {
+			errs = append(errs, fe)
+			rd.failed[fe.Field] = true
+		}
+	}
 
-func (v *Valid) parseParamHttpFields(data url.Values) map[string]string {
-	ret := make(map[string]string, len(data))
-	for k, v := range data {
-		ret[k] = v[0]
+	if len(errs) > 0 {
+		return nil, errs
 	}
 
-	return ret
+	return ret, nil
 }
 
-func (v *Valid) GetString(reg *Regulation) (bool, string, error) {
-	value, exist := v.MapData[reg.Name]
+// 获取标量参数的字符串值 对象和数组返回类型错误
+func (v *Valid) scalar(reg *Regulation) (bool, string, error) {
+	val, exist := v.lookup(reg.Name)
+	if !exist {
+		return false, "", nil
+	}
 
-	if reg.Required && !exist {
-		return false, "", errors.New(reg.Desc + "不能为空")
+	value, ok := scalarString(val)
+	if !ok {
+		return true, "", reg.newError("type", CodeType, "")
 	}
 
-	if reg.Required && (!exist || value == "") {
-		return false, "", errors.New(reg.Desc + "不能为空")
+	return true, value, nil
+}
+
+// 获取数组参数 url参数按,拆分
+func (v *Valid) array(reg *Regulation) (bool, []any, error) {
+	val, exist := v.lookup(reg.Name)
+	if !exist || val == nil {
+		return false, nil, nil
 	}
 
-	var tmp string
-	if !exist {
-		tmp = reg.defToString()
+	switch t := val.(type) {
+	case []any:
+		return true, t, nil
+	case string:
+		ret := make([]any, 0)
+		for _, s := range strings.Split(t, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				ret = append(ret, s)
+			}
+		}
+		return true, ret, nil
 	}
 
-	//获得字符长度
-	num := int64(utf8.RuneCountInString(tmp))
+	return true, nil, reg.newError("type", CodeType, "")
+}
 
-	//判断长度
-	if num > 0 && reg.Min > 0 {
-		if num < reg.Min {
-			return exist, "", errors.New(reg.Desc + "最少" + strconv.FormatInt(reg.Min, 10) + "个字")
-		}
+func (v *Valid) GetString(reg *Regulation) (bool, string, error) {
+	exist, value, err := v.scalar(reg)
+	if err != nil {
+		return exist, "", err
 	}
 
-	if reg.Max == 0 { //限定下 数据库存储 普通情况 最大65535
-		reg.Max = 65535
+	if reg.Required && (!exist || value == "") {
+		return false, "", reg.newError("required", CodeRequired, "")
 	}
 
-	if num > reg.Max {
-		return exist, "", errors.New(reg.Desc + "最多" + strconv.FormatInt(reg.Max, 10) + "个字")
+	if !exist {
+		value = reg.defToString()
 	}
 
-	if !reg.checkRegexp(value) {
-		return exist, "", errors.New(reg.Desc + "正则校验失败")
+	if fe := reg.checkString(value); fe != nil {
+		return exist, "", fe
 	}
 
-	return exist, tmp, nil
+	return exist, value, nil
 }
 
 func (v *Valid) GetInt64(reg *Regulation) (bool, int64, error) {
-	value, exist := v.MapData[reg.Name]
+	exist, value, err := v.scalar(reg)
+	if err != nil {
+		return exist, 0, err
+	}
 
 	if reg.Required && !exist {
-		return false, 0, errors.New(reg.Desc + "不能为空")
+		return false, 0, reg.newError("required", CodeRequired, "")
 	}
 
-	var (
-		tmp int64
-		err error
-	)
+	var tmp int64
 	if !exist {
 		tmp = reg.defToInt64()
 	} else {
 		if !reg.checkRegexp(value) {
-			return false, 0, errors.New(reg.Desc + "正则校验失败")
+			return false, 0, reg.newError("reg", CodePattern, reg.Reg)
 		}
 
-		tmp, err = strconv.ParseInt(value, 10, 64)
+		tmp, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
 		if err != nil {
-			return false, 0, errors.New(reg.Desc + "类型错误")
+			return false, 0, reg.newError("type", CodeType, "")
 		}
 	}
 
-	//判断大小
-	if tmp < reg.Min {
-		return exist, 0, errors.New(reg.Desc + "不能小于" + strconv.FormatInt(reg.Min, 10))
+	if fe := reg.checkInt(tmp); fe != nil {
+		return exist, 0, fe
 	}
 
-	if reg.Max > 0 {
-		if tmp > reg.Max {
-			return exist, 0, errors.New(reg.Desc + "不能大于" + strconv.FormatInt(reg.Max, 10))
+	return exist, tmp, nil
+}
+
+// GetFloat64 获取小数 MinFloat MaxFloat为取值范围 均为0时使用Min Max Precision为最多小数位数
+func (v *Valid) GetFloat64(reg *Regulation) (bool, float64, error) {
+	exist, value, err := v.scalar(reg)
+	if err != nil {
+		return exist, 0, err
+	}
+
+	if reg.Required && !exist {
+		return false, 0, reg.newError("required", CodeRequired, "")
+	}
+
+	var tmp float64
+	if !exist {
+		tmp = reg.defToFloat64()
+	} else {
+		tmp, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return false, 0, reg.newError("type", CodeType, "")
 		}
 	}
 
+	if fe := reg.checkFloat(tmp); fe != nil {
+		return exist, 0, fe
+	}
+
 	return exist, tmp, nil
 }
 
+// GetBool 获取布尔值 支持json布尔值 1 0 true false on off yes no
+func (v *Valid) GetBool(reg *Regulation) (bool, bool, error) {
+	exist, value, err := v.scalar(reg)
+	if err != nil {
+		return exist, false, err
+	}
+
+	if reg.Required && (!exist || value == "") {
+		return false, false, reg.newError("required", CodeRequired, "")
+	}
+
+	if !exist {
+		return false, reg.defToInt64() != 0, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "on", "yes":
+		return true, true, nil
+	case "", "0", "false", "off", "no":
+		return true, false, nil
+	}
+
+	return true, false, reg.newError("type", CodeType, "")
+}
+
 func (v *Valid) GetIntArray(reg *Regulation) (bool, []int64, error) {
-	value, exist := v.MapData[reg.Name]
+	exist, arr, err := v.array(reg)
+	if err != nil {
+		return exist, []int64{}, err
+	}
 
-	if reg.Required && !exist {
-		return false, []int64{}, errors.New(reg.Desc + "不能为空")
+	if reg.Required && len(arr) == 0 {
+		return false, []int64{}, reg.newError("required", CodeRequired, "")
 	}
 
-	ret := make([]int64, 0)
-	is := strings.Split(value, ",")
-	for _, va := range is {
-		if va == "" {
-			continue
+	ret := make([]int64, 0, len(arr))
+	for _, e := range arr {
+		va, ok := scalarString(e)
+		if !ok {
+			return exist, []int64{}, reg.newError("type", CodeType, "")
 		}
 
-		if !reg.checkRegexp(value) {
-			return exist, []int64{}, errors.New(reg.Desc + "正则校验失败")
+		if !reg.checkRegexp(va) {
+			return exist, []int64{}, reg.newError("reg", CodePattern, reg.Reg)
 		}
 
-		i, _ := strconv.ParseInt(strings.TrimSpace(va), 10, 64)
-
-		if i < reg.Min {
-			return exist, []int64{}, errors.New(reg.Desc + "不能小于" + strconv.FormatInt(reg.Min, 10))
+		i, err := strconv.ParseInt(strings.TrimSpace(va), 10, 64)
+		if err != nil {
+			return exist, []int64{}, reg.newError("type", CodeType, "")
 		}
 
-		if reg.Max > 0 {
-			if i > reg.Max {
-				return exist, []int64{}, errors.New(reg.Desc + "不能大于" + strconv.FormatInt(reg.Max, 10))
-			}
+		if fe := reg.checkInt(i); fe != nil {
+			return exist, []int64{}, fe
 		}
 
 		ret = append(ret, i)
 	}
 
-	return true, ret, nil
+	return exist, ret, nil
 }
 
 func (v *Valid) GetStringArray(reg *Regulation) (bool, []string, error) {
-	value, exist := v.MapData[reg.Name]
-
-	if reg.Required && (!exist || value == "") {
-		return false, []string{}, errors.New(reg.Desc + "不能为空")
+	exist, arr, err := v.array(reg)
+	if err != nil {
+		return exist, []string{}, err
 	}
 
-	if reg.Max == 0 { //数据库存储时 一般最大65535
-		reg.Max = 65535
+	if reg.Required && len(arr) == 0 {
+		return false, []string{}, reg.newError("required", CodeRequired, "")
 	}
 
-	var (
-		rc  *regexp.Regexp
-		err error
-	)
-	if reg.Reg != "" {
-		rc, err = regexp.Compile(reg.Reg)
-		if err != nil {
-			return exist, []string{}, errors.New("正则初始化错误:" + err.Error())
+	ret := make([]string, 0, len(arr))
+	for _, e := range arr {
+		tmp, ok := scalarString(e)
+		if !ok {
+			return exist, []string{}, reg.newError("type", CodeType, "")
 		}
-	}
 
-	ret := make([]string, 0)
-	is := strings.Split(value, ",")
-	for _, v := range is {
-		tmp := strings.TrimSpace(v)
-		if tmp == "" {
-			continue
+		if fe := reg.checkString(tmp); fe != nil {
+			return exist, []string{}, fe
 		}
 
-		num := int64(utf8.RuneCountInString(tmp))
+		ret = append(ret, tmp)
+	}
 
-		if reg.Min > 0 {
-			if num < reg.Min {
-				return exist, []string{}, errors.New(reg.Desc + "最少" + strconv.FormatInt(reg.Min, 10) + "个字")
-			}
-		}
+	return exist, ret, nil
+}
 
-		if num > reg.Max {
-			return exist, []string{}, errors.New(reg.Desc + "最多" + strconv.FormatInt(reg.Max, 10) + "个字")
-		}
+// GetObject 获取json对象 返回的*Valid可继续按规则校验 不存在时返回空对象
+func (v *Valid) GetObject(reg *Regulation) (bool, *Valid, error) {
+	val, exist := v.lookup(reg.Name)
+	if exist && val == nil {
+		exist = false
+	}
 
-		if rc != nil {
-			b := rc.MatchString(tmp)
-			if !b {
-				return exist, []string{}, errors.New(reg.Desc + "无法通过规则校验" + tmp)
-			}
+	if reg.Required && !exist {
+		return false, newTreeValid(nil), reg.newError("required", CodeRequired, "")
+	}
+
+	if !exist {
+		return false, newTreeValid(nil), nil
+	}
+
+	obj, ok := val.(map[string]any)
+	if !ok {
+		return true, newTreeValid(nil), reg.newError("type", CodeType, "")
+	}
+
+	return true, newTreeValid(obj), nil
+}
+
+// GetObjectArray 获取json对象数组 Min Max为元素个数限制
+func (v *Valid) GetObjectArray(reg *Regulation) (bool, []*Valid, error) {
+	val, exist := v.lookup(reg.Name)
+	if exist && val == nil {
+		exist = false
+	}
+
+	arr, ok := val.([]any)
+	if exist && !ok {
+		return true, []*Valid{}, reg.newError("type", CodeType, "")
+	}
+
+	if reg.Required && len(arr) == 0 {
+		return false, []*Valid{}, reg.newError("required", CodeRequired, "")
+	}
+
+	if exist {
+		if fe := reg.checkCount(len(arr)); fe != nil {
+			return true, []*Valid{}, fe
 		}
+	}
 
-		ret = append(ret, tmp)
+	ret := make([]*Valid, 0, len(arr))
+	for i, e := range arr {
+		obj, ok := e.(map[string]any)
+		if !ok {
+			return true, []*Valid{}, newFieldError(reg.Name+"["+strconv.Itoa(i)+"]", reg.Desc+"["+strconv.Itoa(i)+"]", "type", CodeType, "")
+		}
+		ret = append(ret, newTreeValid(obj))
 	}
 
-	return true, ret, nil
+	return exist, ret, nil
 }
 
 func (r *Regulation) defToString() string {
@@ -340,12 +491,113 @@ func (r *Regulation) defToInt64() int64 {
 	return 0
 }
 
+func (r *Regulation) defToFloat64() float64 {
+	switch t := r.Def.(type) {
+	case float32:
+		return float64(t)
+	case float64:
+		return t
+	case string:
+		f, _ := strconv.ParseFloat(t, 64)
+		return f
+	}
+
+	return float64(r.defToInt64())
+}
+
+// 校验字符串长度和正则规则 空字符串不校验最小长度
+func (r *Regulation) checkString(value string) *FieldError {
+	//获得字符长度
+	num := int64(utf8.RuneCountInString(value))
+
+	//判断长度
+	if num > 0 && r.Min > 0 {
+		if num < r.Min {
+			return r.newError("min", CodeTooShort, strconv.FormatInt(r.Min, 10))
+		}
+	}
+
+	maxLen := r.Max
+	if maxLen == 0 { //限定下 数据库存储 普通情况 最大65535
+		maxLen = 65535
+	}
+
+	if num > maxLen {
+		return r.newError("max", CodeTooLong, strconv.FormatInt(maxLen, 10))
+	}
+
+	if !r.checkRegexp(value) {
+		return r.newError("reg", CodePattern, r.Reg)
+	}
+
+	return nil
+}
+
+// 校验数字大小 Max为0时不限制最大值
+func (r *Regulation) checkInt(n int64) *FieldError {
+	if n < r.Min {
+		return r.newError("min", CodeTooSmall, strconv.FormatInt(r.Min, 10))
+	}
+
+	if r.Max > 0 {
+		if n > r.Max {
+			return r.newError("max", CodeTooLarge, strconv.FormatInt(r.Max, 10))
+		}
+	}
+
+	return nil
+}
+
+// 校验小数范围和小数位数
+func (r *Regulation) checkFloat(n float64) *FieldError {
+	minF, maxF := r.MinFloat, r.MaxFloat
+	if minF == 0 && maxF == 0 {
+		minF, maxF = float64(r.Min), float64(r.Max)
+	}
+
+	if n < minF {
+		return r.newError("min", CodeTooSmall, strconv.FormatFloat(minF, 'f', -1, 64))
+	}
+
+	if maxF != 0 && n > maxF {
+		return r.newError("max", CodeTooLarge, strconv.FormatFloat(maxF, 'f', -1, 64))
+	}
+
+	if r.Precision > 0 {
+		_, dec, _ := strings.Cut(strconv.FormatFloat(n, 'f', -1, 64), ".")
+		if len(dec) > r.Precision {
+			return r.newError("precision", CodePrecision, strconv.Itoa(r.Precision))
+		}
+	}
+
+	return nil
+}
+
+// 校验数组元素个数
+func (r *Regulation) checkCount(n int) *FieldError {
+	if r.Min > 0 && int64(n) < r.Min {
+		return r.newError("min", CodeTooFew, strconv.FormatInt(r.Min, 10))
+	}
+
+	if r.Max > 0 && int64(n) > r.Max {
+		return r.newError("max", CodeTooMany, strconv.FormatInt(r.Max, 10))
+	}
+
+	return nil
+}
+
+// 正则规则错误时视为校验失败 可通过NewRules在启动时检查
 func (r *Regulation) checkRegexp(value string) bool {
 	if r.Reg != "" && value != "" {
-		b, e := regexp.MatchString(r.Reg, value)
-		if !b || e != nil {
-			return false
+		rc := r.rc
+		if rc == nil {
+			var err error
+			if rc, err = compileReg(r.Reg); err != nil {
+				return false
+			}
 		}
+
+		return rc.MatchString(value)
 	}
 
 	return true
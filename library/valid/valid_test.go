@@ -0,0 +1,99 @@
+package valid
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNewRules(t *testing.T) {
+	tests := []struct {
+		regs []*Regulation
+		ok   bool
+	}{
+		{[]*Regulation{{Name: "a", CheckType: String, Reg: "^[a-z]+$"}}, true},
+		{[]*Regulation{{Name: "a", CheckType: String, Reg: "^[a-z+$"}}, false},
+		{[]*Regulation{{CheckType: String}}, false},
+		{[]*Regulation{nil}, false},
+		{[]*Regulation{{Name: "a", CheckType: Enum}}, false},
+		{[]*Regulation{{Name: "a", CheckType: Mobile + 1}}, false},
+	}
+
+	for i, tt := range tests {
+		_, err := NewRules(tt.regs)
+		if (err == nil) != tt.ok {
+			t.Errorf("第%d项 NewRules错误 %v 期望通过 %v", i, err, tt.ok)
+		}
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MustRules规则错误时应panic")
+			}
+		}()
+		MustRules([]*Regulation{{Name: "a", CheckType: String, Reg: "("}})
+	}()
+
+	//创建后修改原规则不影响Rules
+	regs := []*Regulation{{Name: "name", Desc: "姓名", CheckType: String, Reg: "^[a-z]+$"}}
+	rs := MustRules(regs)
+	regs[0].Reg = "^[0-9]+$"
+
+	v, _ := NewValid(nil, []byte(`{"name":"abc"}`))
+	if data, err := rs.Check(v); err != nil || data["name"] != "abc" {
+		t.Errorf("预编译规则校验 %v %v", data, err)
+	}
+	if _, err := v.RegData(regs); err == nil {
+		t.Error("修改后的原规则应校验失败")
+	}
+
+	//未预编译时 正则错误视为校验失败
+	_, fe := checkOne(t, `{"name":"abc"}`, &Regulation{Name: "name", CheckType: String, Reg: "^[a-z+$"})
+	if fe == nil || fe.Code != CodePattern {
+		t.Errorf("错误的正则 期望 %s 实际 %v", CodePattern, fe)
+	}
+}
+
+func BenchmarkRegData(b *testing.B) {
+	v, _ := NewValid(nil, []byte(`{"username":"solaa51","password":"123456","tags":["go","web","api"]}`))
+	regs := []*Regulation{
+		{Name: "username", Desc: "用户名", CheckType: String, Required: true, Min: 5, Max: 20, Reg: "^[a-zA-Z][a-zA-Z0-9]{4,31}$"},
+		{Name: "password", Desc: "密码", CheckType: String, Required: true, Min: 4, Max: 20},
+		{Name: "tags", Desc: "标签", CheckType: ArrayString, Reg: "^[a-z]+$"},
+	}
+	rs := MustRules(regs)
+
+	//未缓存时每次调用regexp.MatchString 正则另行校验 其余规则同上
+	plain := make([]*Regulation, len(regs))
+	for i, r := range regs {
+		c := *r
+		c.Reg = ""
+		plain[i] = &c
+	}
+	tags := []string{"go", "web", "api"}
+
+	b.Run("nocache", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = v.RegData(plain)
+			_, _ = regexp.MatchString(regs[0].Reg, "solaa51")
+			for _, tag := range tags {
+				_, _ = regexp.MatchString(regs[2].Reg, tag)
+			}
+		}
+	})
+
+	b.Run("cache", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = v.RegData(regs)
+		}
+	})
+
+	b.Run("rules", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = rs.Check(v)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package wsHub
+
+import (
+	"encoding/json"
+	"github.com/solaa51/swagger/log/bufWriter"
+)
+
+// Broker 多实例间转发广播消息 需将消息发送给全部订阅者
+// library/nats的*natsv2.Nats已实现该接口
+type Broker interface {
+	Publish(subject string, data []byte) error
+	Subscribe(subject string, fn func(data []byte)) error
+}
+
+// 转发的消息结构
+type envelope struct {
+	Node string `json:"node"` //发出消息的节点
+	Room string `json:"room"` //房间 为空时发送给全部连接
+	Data []byte `json:"data"`
+}
+
+// SetBroker 设置多实例转发 广播时同时发布到subject 并将其他节点发布的消息发送给本节点的连接
+//
+//	hub.SetBroker(natsv2.Default(), "ws.broadcast")
+func (h *Hub) SetBroker(b Broker, subject string) error {
+	err := b.Subscribe(subject, func(data []byte) {
+		var e envelope
+		if err := json.Unmarshal(data, &e); err != nil {
+			bufWriter.Warn("wsHub无法解析转发消息", err)
+			return
+		}
+
+		if e.Node == h.nodeId {
+			return
+		}
+
+		h.deliver(e.Room, e.Data)
+	})
+	if err != nil {
+		return err
+	}
+
+	h.broker = b
+	h.subject = subject
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package wsHub
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/solaa51/swagger/context"
+	"sync"
+	"time"
+)
+
+const writeWait = 10 * time.Second //单次写入超时
+
+// Conn 单个websocket连接 消息以文本帧发送
+type Conn struct {
+	Id  string           //连接ID
+	Ctx *context.Context //建立连接时的请求副本 可读取参数 ClientIp 及中间件写入的数据
+
+	hub   *Hub
+	ws    *websocket.Conn
+	send  chan []byte
+	done  chan struct{}
+	rooms map[string]struct{} //已加入的房间 由hub.lock保护
+
+	closeOnce sync.Once
+	closeCode int //关闭时发送给客户端的状态码
+}
+
+// Send 发送消息 放入发送队列后立即返回 队列满时关闭连接
+func (c *Conn) Send(msg []byte) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case c.send <- msg:
+		return nil
+	default:
+		c.Close()
+		return ErrQueueFull
+	}
+}
+
+// SendJSON 以json格式发送消息
+func (c *Conn) SendJSON(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.Send(b)
+}
+
+// Join 加入房间
+func (c *Conn) Join(room string) {
+	h := c.hub
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if _, ok := h.conns[c]; !ok { //已关闭
+		return
+	}
+
+	c.rooms[room] = struct{}{}
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[*Conn]struct{})
+	}
+	h.rooms[room][c] = struct{}{}
+}
+
+// Leave 离开房间
+func (c *Conn) Leave(room string) {
+	c.hub.lock.Lock()
+	defer c.hub.lock.Unlock()
+
+	c.hub.leave(c, room)
+}
+
+// Rooms 已加入的房间
+func (c *Conn) Rooms() []string {
+	c.hub.lock.RLock()
+	defer c.hub.lock.RUnlock()
+
+	ret := make([]string, 0, len(c.rooms))
+	for room := range c.rooms {
+		ret = append(ret, room)
+	}
+
+	return ret
+}
+
+// Done 连接关闭时关闭
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close 关闭连接
+func (c *Conn) Close() {
+	c.close(websocket.CloseNormalClosure)
+}
+
+// 服务关闭 通知客户端稍后重连
+func (c *Conn) shutdown() {
+	c.close(websocket.CloseGoingAway)
+}
+
+func (c *Conn) close(code int) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		close(c.done)
+		c.hub.remove(c)
+	})
+}
+
+// 读取客户端消息 超过读取期限未收到任何消息或pong时断开
+func (c *Conn) readPump(maxSize int64, wait time.Duration) {
+	defer c.Close()
+
+	c.ws.SetReadLimit(maxSize)
+	_ = c.ws.SetReadDeadline(time.Now().Add(wait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(wait))
+	})
+
+	for {
+		_, msg, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = c.ws.SetReadDeadline(time.Now().Add(wait))
+
+		if c.hub.onMessage != nil {
+			c.hub.onMessage(c, msg)
+		}
+	}
+}
+
+// 发送队列中的消息和心跳 连接上的写入只在该goroutine中进行
+func (c *Conn) writePump(ping time.Duration) {
+	t := time.NewTicker(ping)
+	defer func() {
+		t.Stop()
+		_ = c.ws.Close()
+		c.hub.wg.Done()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(c.closeCode, ""), time.Now().Add(writeWait))
+			return
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+				c.Close()
+				return
+			}
+		case <-t.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package wsHub
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/solaa51/swagger/app"
+	"github.com/solaa51/swagger/appConfig"
+	"github.com/solaa51/swagger/context"
+	"github.com/solaa51/swagger/log/bufWriter"
+	"github.com/solaa51/swagger/snowflake"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// websocket连接管理 房间 广播 心跳 多实例转发
+// 每个连接有独立的发送队列 发送不阻塞 队列满时断开该连接
+// 服务关闭时通过app.RegistClose通知客户端并关闭全部连接
+
+// ErrClosed 连接或Hub已关闭
+var ErrClosed = errors.New("wsHub: 连接已关闭")
+
+// ErrQueueFull 发送队列已满 连接将被关闭
+var ErrQueueFull = errors.New("wsHub: 发送队列已满")
+
+// Hub 连接管理器
+type Hub struct {
+	lock   sync.RWMutex
+	conns  map[*Conn]struct{}
+	rooms  map[string]map[*Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup //进行中的发送goroutine 关闭时等待关闭帧发送完成
+
+	onMessage func(c *Conn, msg []byte)
+	onClose   func(c *Conn)
+
+	broker  Broker
+	subject string
+	nodeId  string //区分消息来源 不处理本节点发出的转发消息
+}
+
+// New 创建Hub 服务关闭时自动关闭全部连接
+func New() *Hub {
+	h := &Hub{
+		conns:  make(map[*Conn]struct{}),
+		rooms:  make(map[string]map[*Conn]struct{}),
+		nodeId: snowflake.ID(),
+	}
+
+	app.RegistClose(h.Close)
+
+	return h
+}
+
+// OnMessage 设置收到客户端消息时的处理方法 在该连接的读取goroutine中依次调用
+func (h *Hub) OnMessage(f func(c *Conn, msg []byte)) {
+	h.onMessage = f
+}
+
+// OnClose 设置连接关闭时的处理方法
+func (h *Hub) OnClose(f func(c *Conn)) {
+	h.onClose = f
+}
+
+// Serve 升级请求为websocket并加入Hub 不阻塞 处理方法可直接返回
+// 升级失败时已输出错误信息
+func (h *Hub) Serve(ctx *context.Context) (*Conn, error) {
+	h.lock.RLock()
+	closed := h.closed
+	h.lock.RUnlock()
+	if closed {
+		ctx.String(http.StatusServiceUnavailable, "服务关闭中")
+		return nil, ErrClosed
+	}
+
+	ws, err := ctx.WebSocket()
+	if err != nil {
+		return nil, err
+	}
+
+	conf := appConfig.Info().WebSocket
+	c := &Conn{
+		Id:    snowflake.ID(),
+		Ctx:   ctx.Copy(),
+		hub:   h,
+		ws:    ws,
+		send:  make(chan []byte, conf.SendQueue),
+		done:  make(chan struct{}),
+		rooms: make(map[string]struct{}),
+	}
+
+	h.lock.Lock()
+	if h.closed {
+		h.lock.Unlock()
+		_ = ws.Close()
+		return nil, ErrClosed
+	}
+	h.conns[c] = struct{}{}
+	h.lock.Unlock()
+
+	ping := time.Duration(conf.PingInterval) * time.Second
+	h.wg.Add(1)
+	go c.writePump(ping)
+	go c.readPump(conf.MaxMessageSize, ping*2)
+
+	return c, nil
+}
+
+// Count 当前节点的连接数
+func (h *Hub) Count() int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return len(h.conns)
+}
+
+// RoomCount 当前节点房间内的连接数
+func (h *Hub) RoomCount(room string) int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return len(h.rooms[room])
+}
+
+// Broadcast 向全部连接发送消息 设置Broker时同时发送到其他节点
+func (h *Hub) Broadcast(msg []byte) {
+	h.BroadcastRoom("", msg)
+}
+
+// BroadcastRoom 向房间内的连接发送消息 room为空时发送给全部连接
+func (h *Hub) BroadcastRoom(room string, msg []byte) {
+	h.deliver(room, msg)
+
+	if h.broker != nil {
+		b, _ := json.Marshal(envelope{Node: h.nodeId, Room: room, Data: msg})
+		if err := h.broker.Publish(h.subject, b); err != nil {
+			bufWriter.Error("wsHub转发消息失败", err)
+		}
+	}
+}
+
+// BroadcastJSON 以json格式向房间内的连接发送消息
+func (h *Hub) BroadcastJSON(room string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	h.BroadcastRoom(room, b)
+
+	return nil
+}
+
+// 向当前节点的连接发送消息
+func (h *Hub) deliver(room string, msg []byte) {
+	h.lock.RLock()
+	conns := h.conns
+	if room != "" {
+		conns = h.rooms[room]
+	}
+
+	list := make([]*Conn, 0, len(conns))
+	for c := range conns {
+		list = append(list, c)
+	}
+	h.lock.RUnlock()
+
+	for _, c := range list {
+		_ = c.Send(msg)
+	}
+}
+
+// Close 通知客户端服务关闭并断开全部连接 之后不再接受新连接
+func (h *Hub) Close() {
+	h.lock.Lock()
+	h.closed = true
+	list := make([]*Conn, 0, len(h.conns))
+	for c := range h.conns {
+		list = append(list, c)
+	}
+	h.lock.Unlock()
+
+	for _, c := range list {
+		c.shutdown()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(writeWait):
+		bufWriter.Warn("wsHub关闭连接超时")
+	}
+}
+
+// 连接关闭时移出Hub和房间
+func (h *Hub) remove(c *Conn) {
+	h.lock.Lock()
+	delete(h.conns, c)
+	for room := range c.rooms {
+		h.leave(c, room)
+	}
+	h.lock.Unlock()
+
+	if h.onClose != nil {
+		h.onClose(c)
+	}
+}
+
+// 需持有写锁
+func (h *Hub) leave(c *Conn, room string) {
+	delete(c.rooms, room)
+	if m, ok := h.rooms[room]; ok {
+		delete(m, c)
+		if len(m) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package limiter
 
 import (
 	"github.com/solaa51/swagger/appConfig"
+	"github.com/solaa51/swagger/metrics"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,10 +14,17 @@ var zeroLimiter = Limiter{}
 var globalRate *Limiter
 var mu sync.Mutex
 
+// 全局限流拒绝的请求数
+var rejected atomic.Int64
+
 // 控制全局的限流器
 func init() {
 	parseGlobalRate()
 
+	metrics.NewCounterFunc("swagger_limiter_rejected_total", "全局限流拒绝的请求数", func() float64 {
+		return float64(rejected.Load())
+	})
+
 	go func() {
 		t := time.NewTicker(time.Second * 5)
 		for {
@@ -46,5 +55,15 @@ func Allow() bool {
 		return true
 	}
 
-	return globalRate.Allow()
+	if !globalRate.Allow() {
+		rejected.Add(1)
+		return false
+	}
+
+	return true
+}
+
+// Rejected 返回全局限流拒绝的请求数
+func Rejected() int64 {
+	return rejected.Load()
 }
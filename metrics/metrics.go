@@ -0,0 +1,213 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 监控指标 以Prometheus文本格式输出
+// 框架内置 路由请求统计 限流 运行时 数据库连接池 redis连接池
+// 应用可通过NewCounter NewGauge等注册自定义指标
+
+// Collector 指标收集器 每次输出指标时调用
+type Collector interface {
+	Collect(w *Writer)
+}
+
+// CollectorFunc 函数形式的指标收集器
+type CollectorFunc func(w *Writer)
+
+func (f CollectorFunc) Collect(w *Writer) {
+	f(w)
+}
+
+var (
+	mu         sync.Mutex
+	collectors = make([]Collector, 0)
+	names      = make(map[string]struct{})
+)
+
+var nameReg = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Register 注册指标收集器
+func Register(c Collector) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	collectors = append(collectors, c)
+}
+
+// 注册具名指标 名称不合法或重复时panic 应在初始化阶段调用
+func registerName(name string, labelNames []string, c Collector) {
+	if !nameReg.MatchString(name) {
+		panic("metrics: 指标名称不合法 " + name)
+	}
+
+	for _, v := range labelNames {
+		if !nameReg.MatchString(v) || strings.Contains(v, ":") {
+			panic("metrics: 标签名称不合法 " + name + " " + v)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := names[name]; ok {
+		panic("metrics: 指标名称重复 " + name)
+	}
+	names[name] = struct{}{}
+
+	collectors = append(collectors, c)
+}
+
+// WriteTo 输出全部指标
+func WriteTo(buf *bytes.Buffer) {
+	mu.Lock()
+	cs := slices.Clone(collectors)
+	mu.Unlock()
+
+	w := &Writer{buf: buf}
+	for _, c := range cs {
+		c.Collect(w)
+	}
+}
+
+// Handler 指标输出接口
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		WriteTo(&buf)
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+// 带标签的指标值 按标签值保存
+type vec struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+
+	values sync.Map //标签值拼接 => *value
+}
+
+type value struct {
+	labels []string
+	bits   atomic.Uint64
+}
+
+func (v *value) add(n float64) {
+	for {
+		old := v.bits.Load()
+		if v.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+n)) {
+			return
+		}
+	}
+}
+
+func (v *vec) get(labels []string) *value {
+	if len(labels) != len(v.labelNames) {
+		panic("metrics: 标签数量不匹配 " + v.name)
+	}
+
+	key := strings.Join(labels, "\xff")
+	if val, ok := v.values.Load(key); ok {
+		return val.(*value)
+	}
+
+	val, _ := v.values.LoadOrStore(key, &value{labels: slices.Clone(labels)})
+	return val.(*value)
+}
+
+func (v *vec) Collect(w *Writer) {
+	vals := make([]*value, 0)
+	v.values.Range(func(_, val any) bool {
+		vals = append(vals, val.(*value))
+		return true
+	})
+	slices.SortFunc(vals, func(a, b *value) int {
+		return slices.Compare(a.labels, b.labels)
+	})
+
+	w.Family(v.name, v.typ, v.help)
+	for _, val := range vals {
+		pairs := make([]string, 0, len(v.labelNames)*2)
+		for i, l := range v.labelNames {
+			pairs = append(pairs, l, val.labels[i])
+		}
+		w.Sample(v.name, math.Float64frombits(val.bits.Load()), pairs...)
+	}
+}
+
+// Counter 只增不减的计数器
+type Counter struct {
+	v *vec
+}
+
+// NewCounter 创建并注册计数器 labelNames为标签名称
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{v: &vec{name: name, help: help, typ: "counter", labelNames: labelNames}}
+	registerName(name, labelNames, c.v)
+
+	return c
+}
+
+// Inc 计数加1 labels为标签值 与标签名称一一对应
+func (c *Counter) Inc(labels ...string) {
+	c.v.get(labels).add(1)
+}
+
+// Add 计数增加n n不能为负数
+func (c *Counter) Add(n float64, labels ...string) {
+	if n < 0 {
+		return
+	}
+	c.v.get(labels).add(n)
+}
+
+// Gauge 可增可减的测量值
+type Gauge struct {
+	v *vec
+}
+
+// NewGauge 创建并注册测量值 labelNames为标签名称
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	g := &Gauge{v: &vec{name: name, help: help, typ: "gauge", labelNames: labelNames}}
+	registerName(name, labelNames, g.v)
+
+	return g
+}
+
+// Set 设置值 labels为标签值 与标签名称一一对应
+func (g *Gauge) Set(n float64, labels ...string) {
+	g.v.get(labels).bits.Store(math.Float64bits(n))
+}
+
+// Add 增加值 n可为负数
+func (g *Gauge) Add(n float64, labels ...string) {
+	g.v.get(labels).add(n)
+}
+
+// NewCounterFunc 创建并注册由函数提供值的计数器 适用于已有计数的场景
+func NewCounterFunc(name, help string, f func() float64) {
+	registerName(name, nil, CollectorFunc(func(w *Writer) {
+		w.Family(name, "counter", help)
+		w.Sample(name, f())
+	}))
+}
+
+// NewGaugeFunc 创建并注册由函数提供值的测量值
+func NewGaugeFunc(name, help string, f func() float64) {
+	registerName(name, nil, CollectorFunc(func(w *Writer) {
+		w.Family(name, "gauge", help)
+		w.Sample(name, f())
+	}))
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"runtime"
+)
+
+// go运行时指标
+
+func init() {
+	Register(CollectorFunc(collectRuntime))
+}
+
+func collectRuntime(w *Writer) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	w.Family("go_goroutines", "gauge", "当前goroutine数量")
+	w.Sample("go_goroutines", float64(runtime.NumGoroutine()))
+
+	for _, v := range []struct {
+		name  string
+		typ   string
+		help  string
+		value float64
+	}{
+		{"go_memstats_sys_bytes", "gauge", "系统分配的内存", float64(ms.Sys)},
+		{"go_memstats_alloc_bytes", "gauge", "堆上已分配的内存", float64(ms.Alloc)},
+		{"go_memstats_alloc_bytes_total", "counter", "堆上累计分配的内存", float64(ms.TotalAlloc)},
+		{"go_memstats_heap_inuse_bytes", "gauge", "使用中的堆内存", float64(ms.HeapInuse)},
+		{"go_memstats_heap_idle_bytes", "gauge", "空闲的堆内存", float64(ms.HeapIdle)},
+		{"go_memstats_heap_released_bytes", "gauge", "已归还系统的堆内存", float64(ms.HeapReleased)},
+		{"go_memstats_heap_objects", "gauge", "堆上的对象数量", float64(ms.HeapObjects)},
+		{"go_gc_cycles_total", "counter", "gc完成次数", float64(ms.NumGC)},
+		{"go_gc_pause_seconds_total", "counter", "gc累计暂停时间", float64(ms.PauseTotalNs) / 1e9},
+	} {
+		w.Family(v.name, v.typ, v.help)
+		w.Sample(v.name, v.value)
+	}
+}
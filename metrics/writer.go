@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Writer Prometheus文本格式输出
+type Writer struct {
+	buf *bytes.Buffer
+}
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// Family 输出指标的说明和类型 typ为counter gauge histogram untyped
+func (w *Writer) Family(name, typ, help string) {
+	if help != "" {
+		w.buf.WriteString("# HELP " + name + " " + helpReplacer.Replace(help) + "\n")
+	}
+	w.buf.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// Sample 输出一个指标值 labels为成对的标签名称和标签值
+func (w *Writer) Sample(name string, value float64, labels ...string) {
+	w.buf.WriteString(name)
+
+	if len(labels) >= 2 {
+		w.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			w.buf.WriteString(labels[i] + `="` + labelReplacer.Replace(labels[i+1]) + `"`)
+		}
+		w.buf.WriteByte('}')
+	}
+
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.buf.WriteByte('\n')
+}
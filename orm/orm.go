@@ -2,6 +2,7 @@ package orm
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"github.com/solaa51/swagger/cFunc"
 	"github.com/solaa51/swagger/configFiles"
 	"github.com/solaa51/swagger/log/bufWriter"
+	"github.com/solaa51/swagger/metrics"
 	"github.com/solaa51/swagger/watchConfig"
 	"golang.org/x/crypto/ssh"
 	"gopkg.in/yaml.v3"
@@ -18,8 +20,10 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
+	"maps"
 	"net"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,13 +33,26 @@ import (
 //自动管理 数据库的连接与更新 配置文件更新时，实例也会同步更新
 
 func GetDb(dbUName string) (*gorm.DB, DbConf, error) {
-	if _, ok := dbInstances[dbUName]; ok {
-		return dbInstances[dbUName].dbIns, dbInstances[dbUName].dbConf, nil
+	dbLock.RLock()
+	d, ok := dbInstances[dbUName]
+	dbLock.RUnlock()
+	if ok {
+		return d.dbIns, d.dbConf, nil
 	}
 
 	return nil, DbConf{}, errors.New("没找到对应数据库示例")
 }
 
+// GetDbCtx 获取绑定ctx的数据库实例 ctx取消或超时时中断查询 一般传入请求的ctx.Ctx
+func GetDbCtx(ctx context.Context, dbUName string) (*gorm.DB, DbConf, error) {
+	db, conf, err := GetDb(dbUName)
+	if err != nil {
+		return nil, conf, err
+	}
+
+	return db.WithContext(ctx), conf, nil
+}
+
 // ShowSql 为数据库连接实例开启sql日志
 func ShowSql(db *gorm.DB) {
 	db.Logger = logger.Default.LogMode(logger.Info)
@@ -78,6 +95,8 @@ func init() {
 	//实例化数据库连接
 	connectDb()
 
+	metrics.Register(metrics.CollectorFunc(collectDbStats))
+
 	//开启数据库配置文件监控
 	go func() {
 		dbConfigNotifyChan, err := watchConfig.AddWatch(dbConfigFile)
@@ -186,7 +205,10 @@ func connectDb() {
 	}
 
 	for _, v := range configParse.Dbs {
-		if dd, ok := dbInstances[v.UName]; ok { //已存在连接
+		dbLock.RLock()
+		dd, ok := dbInstances[v.UName]
+		dbLock.RUnlock()
+		if ok { //已存在连接
 			//判断是否有变化
 			if dd.dbConf.Host != v.Host || dd.dbConf.Pass != v.Pass || dd.dbConf.Port != v.Port || dd.dbConf.User != v.User || dd.dbConf.Name != v.Name {
 				db, err := link(v)
@@ -210,10 +232,12 @@ func connectDb() {
 				continue
 			}
 
+			dbLock.Lock()
 			dbInstances[v.UName] = &dbInstance{
 				dbConf: v,
 				dbIns:  db,
 			}
+			dbLock.Unlock()
 		}
 	}
 
@@ -254,6 +278,9 @@ type DbConfigParse struct {
 // dbInstances 当前已连接到的数据库实例
 var dbInstances map[string]*dbInstance
 
+// dbLock 配置变更时新增实例 与读取并发
+var dbLock sync.RWMutex
+
 // dbInstance 单个数据库连接实例
 type dbInstance struct {
 	mux    sync.Mutex
@@ -271,10 +298,9 @@ func (d *dbInstance) update(conf DbConf, db *gorm.DB) {
 
 // TableToStruct 将数据库表 转换为struct结构输出
 func TableToStruct(dbUName string, tableName string) {
-	var d *dbInstance
-	if _, ok := dbInstances[dbUName]; ok {
-		d = dbInstances[dbUName]
-	}
+	dbLock.RLock()
+	d := dbInstances[dbUName]
+	dbLock.RUnlock()
 
 	if d == nil {
 		bufWriter.Fatal("获取数据库连接失败：", dbUName)
@@ -430,6 +456,53 @@ func TableToStruct(dbUName string, tableName string) {
 	fmt.Println("}")
 }
 
+// 输出数据库连接池的监控指标 标签db为配置中的uName
+func collectDbStats(w *metrics.Writer) {
+	dbLock.RLock()
+	instances := maps.Clone(dbInstances)
+	dbLock.RUnlock()
+
+	names := make([]string, 0, len(instances))
+	for k := range instances {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+
+	stats := make([]sql.DBStats, 0, len(names))
+	for _, k := range names {
+		d := instances[k]
+		d.mux.Lock()
+		sqlDB, err := d.dbIns.DB()
+		d.mux.Unlock()
+		if err != nil {
+			stats = append(stats, sql.DBStats{})
+			continue
+		}
+		stats = append(stats, sqlDB.Stats())
+	}
+
+	for _, v := range []struct {
+		name  string
+		typ   string
+		help  string
+		value func(s sql.DBStats) float64
+	}{
+		{"swagger_db_max_open_connections", "gauge", "最大连接数", func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+		{"swagger_db_open_connections", "gauge", "当前连接数", func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+		{"swagger_db_in_use_connections", "gauge", "使用中的连接数", func(s sql.DBStats) float64 { return float64(s.InUse) }},
+		{"swagger_db_idle_connections", "gauge", "空闲连接数", func(s sql.DBStats) float64 { return float64(s.Idle) }},
+		{"swagger_db_wait_count_total", "counter", "等待连接的累计次数", func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+		{"swagger_db_wait_duration_seconds_total", "counter", "等待连接的累计时间", func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+		{"swagger_db_max_idle_closed_total", "counter", "因超出最大空闲数关闭的连接数", func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+		{"swagger_db_max_lifetime_closed_total", "counter", "因超出最大存活时间关闭的连接数", func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+	} {
+		w.Family(v.name, v.typ, v.help)
+		for i, k := range names {
+			w.Sample(v.name, v.value(stats[i]), "db", k)
+		}
+	}
+}
+
 /***使用ssh tunnel加密时使用***/
 
 // ssh 隧道代理
@@ -1,3 +1,6 @@
+// Package router 旧版正则路由 已不再参与请求处理
+//
+// Deprecated: 使用routerV2 RouteParse.BindRegexp/MatchRegexp注册正则路由 pprof使用RouteParse.BindPprof
 package router
 
 import (
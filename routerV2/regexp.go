@@ -0,0 +1,161 @@
+package router
+
+import (
+	"github.com/solaa51/swagger/context"
+	"github.com/solaa51/swagger/control"
+	"github.com/solaa51/swagger/handle/handleFuncParse"
+	"github.com/solaa51/swagger/log/bufWriter"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// 正则路由 仅在链表路由未匹配时按注册顺序依次尝试
+// 正则中的分组依次作为调用参数 同时作为命名参数 未命名的分组以序号1 2...作为名称
+
+type regRoute struct {
+	reg *regexp.Regexp
+	seg *Segment
+}
+
+var regRouters = make([]*regRoute, 0)
+
+// BindRegexp 绑定正则路由到函数 正则规则自动追加^和$ 前缀按字面量匹配
+func (r *RouteParse) BindRegexp(pattern string, f func(*context.Context)) *RouteParse {
+	if pattern == "" {
+		bufWriter.Info("BindRegexp空路由,跳过处理")
+		return r
+	}
+
+	fu := handleFuncParse.ParseFuncToRoute(pattern, f)
+	r.addRegexpRouter("BindRegexp", pattern, fu)
+
+	r.reset()
+
+	return r
+}
+
+// MatchRegexp 将正则路由匹配到hangdleFuncParse下面已解析完的方法
+func (r *RouteParse) MatchRegexp(pattern, structFuncName string) *RouteParse {
+	if pattern == "" || structFuncName == "" {
+		return r
+	}
+
+	f := handleFuncParse.MatchAndDelHandler(structFuncName)
+	if f != nil {
+		r.addRegexpRouter("MatchRegexp", pattern, f)
+	}
+
+	r.reset()
+
+	return r
+}
+
+// BindPprof 绑定内置的pprof路由 /debug/pprof/*
+// 可配合Prefix和BindMiddleware使用 为pprof追加访问校验
+func (r *RouteParse) BindPprof() *RouteParse {
+	p := &control.Pprof{}
+	methods := slices.Clone(r.methods)
+	m := slices.Clone(r.Middleware)
+
+	for _, v := range []struct {
+		path string
+		f    func(*context.Context)
+	}{
+		{"debug/pprof/cmdline", p.Cmdline},
+		{"debug/pprof/profile", p.Profile},
+		{"debug/pprof/symbol", p.Symbol},
+		{"debug/pprof/trace", p.Trace},
+	} {
+		r.methods, r.Middleware = slices.Clone(methods), slices.Clone(m)
+		r.BindFunc(v.path, v.f)
+	}
+
+	r.methods, r.Middleware = methods, m
+
+	return r.BindRegexp(`debug/pprof(/\w*)?`, p.Index)
+}
+
+// FitArgs 正则路由的分组数量可多于方法参数 多余的分组忽略 可通过命名参数获取
+func (r *Router) FitArgs(args []string) []string {
+	if r.isRegexp {
+		if n := r.Handler.ArgNum(); len(args) > n {
+			return args[:n]
+		}
+	}
+
+	return args
+}
+
+// 添加正则路由绑定
+func (r *RouteParse) addRegexpRouter(source, pattern string, handler *handleFuncParse.HandleFunc) {
+	if prefix := r.fullPrefix(""); prefix != "" {
+		pattern = regexp.QuoteMeta(prefix+"/") + pattern
+	}
+
+	routers = append(routers, &Router{
+		Path:       pattern,
+		Methods:    slices.Clone(r.methods),
+		Handler:    handler,
+		Middleware: slices.Concat(r.groupMiddleware, r.Middleware),
+		Source:     source,
+		Caller:     bindCaller(),
+		isRegexp:   true,
+	})
+}
+
+// 生成正则路由匹配规则 相同正则规则按请求方法合并
+func addRegexp(router *Router) {
+	var seg *Segment
+	for _, v := range regRouters {
+		if v.seg.Name == router.Path {
+			seg = v.seg
+			break
+		}
+	}
+
+	if seg == nil {
+		reg, err := regexp.Compile(`^` + router.Path + `$`)
+		if err != nil {
+			bufWriter.Fatal("正则路由规则错误", router.Path, err)
+		}
+
+		seg = &Segment{
+			Name:    router.Path,
+			Routers: make(map[string]*Router),
+			Child:   make(map[string]*Segment),
+		}
+		regRouters = append(regRouters, &regRoute{reg: reg, seg: seg})
+	}
+
+	seg.addRouter(router)
+}
+
+// 匹配正则路由 不允许该请求方法的规则跳过
+func (m *matcher) matchRegexp(urlPath string) (*Segment, []string, context.Params) {
+	for _, v := range regRouters {
+		matches := v.reg.FindStringSubmatch(urlPath)
+		if matches == nil {
+			continue
+		}
+
+		args := matches[1:]
+		params := make(context.Params, 0, len(args))
+		for i, name := range v.reg.SubexpNames() {
+			if i == 0 {
+				continue
+			}
+
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			params = append(params, context.Param{Key: name, Value: matches[i]})
+		}
+
+		if m.accept(v.seg, args, params) {
+			return v.seg, args, params
+		}
+	}
+
+	return nil, nil, nil
+}
@@ -5,33 +5,88 @@ import (
 	"github.com/solaa51/swagger/handle/handleFuncParse"
 	"github.com/solaa51/swagger/log/bufWriter"
 	"github.com/solaa51/swagger/middleware"
+	"net/http"
+	"slices"
 	"strings"
 )
 
-var routers = make(map[string]*Router)
+// MethodAny 不限制请求方法
+const MethodAny = "*"
+
+// 路由绑定记录 按绑定顺序保存 同一路由同一请求方法 后绑定的覆盖先绑定的
+var routers = make([]*Router, 0)
 
 // Router 外部通过Router初始化路由规则
 type Router struct {
+	Path       string   //路由规则
+	Methods    []string //允许的请求方法 为空则不限制
 	Handler    *handleFuncParse.HandleFunc
 	Middleware []middleware.Middleware
+	Source     string      //绑定来源 绑定方法名称 未显式绑定的为default
+	Caller     string      //绑定位置 文件:行号
+	Stats      *RouteStats //调用统计
+
+	isRegexp bool //是否为正则路由
 }
 
 // RouteParse 路由解析器
 type RouteParse struct {
+	groupPrefix     string                  //分组前缀 由Group生成 不受Prefix影响
+	groupMiddleware []middleware.Middleware //分组中间件 作用于该解析器的每一次绑定
+
 	prefix     string                  //前缀 多次设置仅覆盖
+	methods    []string                //请求方法限制 当有一次使用后 清空
 	Middleware []middleware.Middleware //中间件 多个 可多次添加 当有一次使用后 清空
 }
 
-// 检测路由
-func (r *RouteParse) checkPath(str string) string {
-	s := strings.ReplaceAll(str, "//", "/")
-	if s[0] == byte('/') {
-		return s[1:]
-	} else {
-		return s
+// Group 生成路由分组解析器
+// 继承当前解析器的分组前缀 前缀 分组中间件 并追加新的前缀和中间件 可多层嵌套
+//
+//	api := (&RouteParse{}).Group("api/v1", &CheckLogin{})
+//	admin := api.Group("admin", &CheckAdmin{}) //路由前缀为api/v1/admin 依次调用CheckLogin CheckAdmin
+func (r *RouteParse) Group(prefix string, m ...middleware.Middleware) *RouteParse {
+	return &RouteParse{
+		groupPrefix:     r.fullPrefix(prefix),
+		groupMiddleware: slices.Concat(r.groupMiddleware, m),
 	}
 }
 
+// 拼接分组前缀 前缀 与路由
+func (r *RouteParse) fullPrefix(path string) string {
+	ps := make([]string, 0, 3)
+	for _, v := range []string{r.groupPrefix, r.prefix, path} {
+		if v = strings.Trim(v, "/"); v != "" {
+			ps = append(ps, v)
+		}
+	}
+
+	return strings.Join(ps, "/")
+}
+
+// 添加路由绑定 路由需包含前缀 分组中间件在前
+func (r *RouteParse) addRouter(source, path string, handler *handleFuncParse.HandleFunc) {
+	routers = append(routers, &Router{
+		Path:       r.checkPath(r.groupPrefix + "/" + path),
+		Methods:    slices.Clone(r.methods),
+		Handler:    handler,
+		Middleware: slices.Concat(r.groupMiddleware, r.Middleware),
+		Source:     source,
+		Caller:     bindCaller(),
+	})
+}
+
+// 单次绑定完成后 清空中间件和请求方法限制
+func (r *RouteParse) reset() {
+	r.methods = r.methods[:0]
+	r.Middleware = r.Middleware[:0]
+}
+
+// 检测路由 去除多余的/
+func (r *RouteParse) checkPath(str string) string {
+	s := strings.Split(str, "/")
+	return strings.Join(slices.DeleteFunc(s, func(v string) bool { return v == "" }), "/")
+}
+
 // Prefix 设置路由前缀
 func (r *RouteParse) Prefix(str string) *RouteParse {
 	r.prefix = str
@@ -40,18 +95,30 @@ func (r *RouteParse) Prefix(str string) *RouteParse {
 
 // BindFunc 绑定函数
 func (r *RouteParse) BindFunc(structFuncName string, f func(*context.Context)) *RouteParse {
-	if r.prefix == "" && structFuncName == "" {
+	if r.groupPrefix == "" && r.prefix == "" && structFuncName == "" {
 		bufWriter.Info("BindFunc空路由,跳过处理")
 		return r
 	}
 
 	fu := handleFuncParse.ParseFuncToRoute(structFuncName, f)
-	routers[r.checkPath(r.prefix+"/"+structFuncName)] = &Router{
-		Handler:    fu,
-		Middleware: r.Middleware,
+	r.addRouter("BindFunc", r.prefix+"/"+structFuncName, fu)
+
+	r.reset()
+
+	return r
+}
+
+// BindFuncE 绑定返回error的函数 返回*context.Error时按其状态码和业务码输出
+func (r *RouteParse) BindFuncE(structFuncName string, f func(*context.Context) error) *RouteParse {
+	if r.groupPrefix == "" && r.prefix == "" && structFuncName == "" {
+		bufWriter.Info("BindFuncE空路由,跳过处理")
+		return r
 	}
 
-	r.Middleware = r.Middleware[:0]
+	fu := handleFuncParse.ParseFuncEToRoute(structFuncName, f)
+	r.addRouter("BindFuncE", r.prefix+"/"+structFuncName, fu)
+
+	r.reset()
 
 	return r
 }
@@ -60,13 +127,10 @@ func (r *RouteParse) BindFunc(structFuncName string, f func(*context.Context)) *
 func (r *RouteParse) BindStruct(strut handleFuncParse.ControllerInstance, aliasName string) *RouteParse {
 	ms := handleFuncParse.ParseStructToRoute(strut, aliasName)
 	for k := range ms {
-		routers[r.checkPath(r.prefix+"/"+k)] = &Router{
-			Handler:    ms[k],
-			Middleware: r.Middleware,
-		}
+		r.addRouter("BindStruct", r.prefix+"/"+k, ms[k])
 	}
 
-	r.Middleware = r.Middleware[:0]
+	r.reset()
 
 	return r
 }
@@ -75,13 +139,10 @@ func (r *RouteParse) BindStruct(strut handleFuncParse.ControllerInstance, aliasN
 func (r *RouteParse) BindStructs(struts ...handleFuncParse.ControllerInstance) *RouteParse {
 	ms := handleFuncParse.ParseStructsToRoute(struts...)
 	for k := range ms {
-		routers[r.checkPath(r.prefix+"/"+k)] = &Router{
-			Handler:    ms[k],
-			Middleware: r.Middleware,
-		}
+		r.addRouter("BindStructs", r.prefix+"/"+k, ms[k])
 	}
 
-	r.Middleware = r.Middleware[:0]
+	r.reset()
 
 	return r
 }
@@ -92,20 +153,17 @@ func (r *RouteParse) MatchFunc(newPath, structFuncName string) *RouteParse {
 		return r
 	}
 
-	if r.prefix == "" && newPath == "" {
+	if r.groupPrefix == "" && r.prefix == "" && newPath == "" {
 		bufWriter.Info("MatchFunc空路由,跳过处理")
 		return r
 	}
 
 	f := handleFuncParse.MatchAndDelHandler(structFuncName)
 	if f != nil {
-		routers[r.checkPath(r.prefix+"/"+newPath)] = &Router{
-			Handler:    f,
-			Middleware: r.Middleware,
-		}
+		r.addRouter("MatchFunc", r.prefix+"/"+newPath, f)
 	}
 
-	r.Middleware = r.Middleware[:0]
+	r.reset()
 
 	return r
 }
@@ -114,13 +172,10 @@ func (r *RouteParse) MatchFunc(newPath, structFuncName string) *RouteParse {
 func (r *RouteParse) MatchPrefixToFunc(structFuncName string) *RouteParse {
 	f := handleFuncParse.MatchAndDelHandler(structFuncName)
 	if f != nil {
-		routers[r.checkPath(r.prefix+"/*")] = &Router{
-			Handler:    f,
-			Middleware: r.Middleware,
-		}
+		r.addRouter("MatchPrefixToFunc", r.prefix+"/*", f)
 	}
 
-	r.Middleware = r.Middleware[:0]
+	r.reset()
 
 	return r
 }
@@ -131,12 +186,53 @@ func (r *RouteParse) BindMiddleware(m ...middleware.Middleware) *RouteParse {
 	return r
 }
 
+// Methods 限制下一次绑定的请求方法 可设置多个 如GET POST
+// 未设置时不限制请求方法
+func (r *RouteParse) Methods(m ...string) *RouteParse {
+	for _, v := range m {
+		v = strings.ToUpper(strings.TrimSpace(v))
+		if v == "" || slices.Contains(r.methods, v) {
+			continue
+		}
+		r.methods = append(r.methods, v)
+	}
+
+	return r
+}
+
+// Get 绑定仅允许GET请求的函数
+func (r *RouteParse) Get(path string, f func(*context.Context)) *RouteParse {
+	return r.Methods(http.MethodGet).BindFunc(path, f)
+}
+
+// Post 绑定仅允许POST请求的函数
+func (r *RouteParse) Post(path string, f func(*context.Context)) *RouteParse {
+	return r.Methods(http.MethodPost).BindFunc(path, f)
+}
+
+// Put 绑定仅允许PUT请求的函数
+func (r *RouteParse) Put(path string, f func(*context.Context)) *RouteParse {
+	return r.Methods(http.MethodPut).BindFunc(path, f)
+}
+
+// Patch 绑定仅允许PATCH请求的函数
+func (r *RouteParse) Patch(path string, f func(*context.Context)) *RouteParse {
+	return r.Methods(http.MethodPatch).BindFunc(path, f)
+}
+
+// Delete 绑定仅允许DELETE请求的函数
+func (r *RouteParse) Delete(path string, f func(*context.Context)) *RouteParse {
+	return r.Methods(http.MethodDelete).BindFunc(path, f)
+}
+
 // 处理无路由规则匹配的func
 func initLastHandlerFunc() {
 	ms := handleFuncParse.ClearHandlerToRoute()
 	for k := range ms {
-		routers[k] = &Router{
+		routers = append(routers, &Router{
+			Path:    k,
 			Handler: ms[k],
-		}
+			Source:  "default",
+		})
 	}
 }
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"github.com/solaa51/swagger/context"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 运行中的路由表查询
+
+// RouteInfo 路由信息
+type RouteInfo struct {
+	Path           string   `json:"path"`           //路由规则 以/开头 正则路由为/加正则表达式
+	Methods        []string `json:"methods"`        //允许的请求方法 *表示不限制
+	StructFuncName string   `json:"structFuncName"` //调用的方法名称
+	Middleware     []string `json:"middleware"`     //中间件类型名称 按调用顺序
+	Regexp         bool     `json:"regexp"`         //是否为正则路由
+	Source         string   `json:"source"`         //绑定来源 绑定方法名称 未显式绑定的为default
+	Caller         string   `json:"caller"`         //绑定位置 文件:行号
+}
+
+// Routes 返回当前生效的路由表 按路由规则排序 正则路由排在最后
+// 需在InitRouterSegment之后调用
+func Routes() []RouteInfo {
+	items := routeList()
+	ret := make([]RouteInfo, 0, len(items))
+	for _, v := range items {
+		ret = append(ret, v.info)
+	}
+
+	return ret
+}
+
+type routeItem struct {
+	info   RouteInfo
+	router *Router
+}
+
+// 遍历路由链表和正则路由 生成路由表
+func routeList() []routeItem {
+	ret := make([]routeItem, 0)
+	var walk func(seg *Segment)
+	walk = func(seg *Segment) {
+		ret = append(ret, segRoutes(segToRoutePath(seg), seg, false)...)
+		for _, v := range seg.children() {
+			walk(v)
+		}
+	}
+	walk(rootSegment)
+
+	slices.SortFunc(ret, func(a, b routeItem) int {
+		return strings.Compare(a.info.Path, b.info.Path)
+	})
+
+	for _, v := range regRouters {
+		ret = append(ret, segRoutes("/"+v.seg.Name, v.seg, true)...)
+	}
+
+	return ret
+}
+
+// 节点下的处理规则 同一处理规则绑定多个请求方法时合并
+func segRoutes(path string, seg *Segment, isRegexp bool) []routeItem {
+	if len(seg.Routers) == 0 {
+		return nil
+	}
+
+	methods := make([]string, 0, len(seg.Routers))
+	for k := range seg.Routers {
+		methods = append(methods, k)
+	}
+	slices.Sort(methods)
+
+	ret := make([]routeItem, 0, len(methods))
+	index := make(map[*Router]int, len(methods))
+	for _, m := range methods {
+		rt := seg.Routers[m]
+		if i, ok := index[rt]; ok {
+			ret[i].info.Methods = append(ret[i].info.Methods, m)
+			continue
+		}
+
+		mws := make([]string, 0, len(rt.Middleware))
+		for _, v := range rt.Middleware {
+			mws = append(mws, reflect.TypeOf(v).String())
+		}
+
+		index[rt] = len(ret)
+		ret = append(ret, routeItem{
+			info: RouteInfo{
+				Path:           path,
+				Methods:        []string{m},
+				StructFuncName: rt.Handler.StructFuncName,
+				Middleware:     mws,
+				Regexp:         isRegexp,
+				Source:         rt.Source,
+				Caller:         rt.Caller,
+			},
+			router: rt,
+		})
+	}
+
+	return ret
+}
+
+// BindRouteTable 绑定内置的路由表查询接口 返回Routes()的json数据
+// 建议配合BindMiddleware或Group追加访问校验
+func (r *RouteParse) BindRouteTable(path string) *RouteParse {
+	return r.BindFunc(path, func(ctx *context.Context) {
+		ctx.RetData = Routes()
+	})
+}
+
+// 查找调用绑定方法的外部位置
+func bindCaller() string {
+	pc := make([]uintptr, 16)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/solaa51/swagger/routerV2.") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
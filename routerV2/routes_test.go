@@ -0,0 +1,32 @@
+package router
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	setupRouters(func(r *RouteParse) {
+		r.Methods(http.MethodGet, http.MethodPost).BindFunc("user/:id", nop)
+		r.BindFunc("a/b", nop)
+		r.Group("api").BindRegexp(`v(\d+)/ping`, nop)
+	})
+
+	want := []RouteInfo{
+		{Path: "/a/b", Methods: []string{"*"}},
+		{Path: "/user/:id", Methods: []string{http.MethodGet, http.MethodPost}},
+		{Path: `/api/v(\d+)/ping`, Methods: []string{"*"}, Regexp: true},
+	}
+
+	routes := Routes()
+	if len(routes) != len(want) {
+		t.Fatalf("路由数量 %d 期望 %d: %+v", len(routes), len(want), routes)
+	}
+
+	for i, v := range routes {
+		if v.Path != want[i].Path || v.Regexp != want[i].Regexp || !slices.Equal(v.Methods, want[i].Methods) {
+			t.Errorf("第%d条路由 %s %v 期望 %s %v", i, v.Path, v.Methods, want[i].Path, want[i].Methods)
+		}
+	}
+}
@@ -1,46 +1,219 @@
 package router
 
 import (
+	"bytes"
 	"github.com/solaa51/swagger/appPath"
-	"github.com/solaa51/swagger/cFunc"
+	"github.com/solaa51/swagger/context"
+	"github.com/solaa51/swagger/log/bufWriter"
+	"net/http"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 )
 
 // 路由匹配链表
+// 路由单节支持三种写法 优先级依次降低：
+//   静态单节 user
+//   命名参数 :id 可限定类型 :id<int> :price<float>
+//   通配符 * 或 *path 仅能作为最后一节 *path会将剩余路径以path为名称保存
 
 type Segment struct {
-	Name   string //路由单节字符串
-	Router *Router
-	Parent *Segment
-	Child  map[string]*Segment
+	Name    string             //路由单节字符串
+	Routers map[string]*Router //按请求方法保存的处理规则 MethodAny表示不限制请求方法
+	Parent  *Segment
+	Child   map[string]*Segment //静态子节点
+	Params  []*Segment          //命名参数子节点 限定类型的排在前面优先匹配
+	Wild    *Segment            //通配符子节点
+
+	param     string //命名参数或通配符的参数名称
+	paramType string //命名参数限定的类型 为空则不限定
+}
+
+// 路由单节类型
+const (
+	segStatic = iota
+	segParam
+	segWild
+)
+
+// 解析路由单节 返回单节类型 参数名称 参数限定类型
+func parseSegName(name string) (int, string, string) {
+	switch {
+	case strings.HasPrefix(name, ":"):
+		pName, pType := name[1:], ""
+		if i := strings.IndexByte(pName, '<'); i > 0 && strings.HasSuffix(pName, ">") {
+			pName, pType = pName[:i], pName[i+1:len(pName)-1]
+		}
+		return segParam, pName, pType
+	case strings.HasPrefix(name, "*"):
+		return segWild, name[1:], ""
+	default:
+		return segStatic, "", ""
+	}
+}
+
+// 检测路由单节值是否符合命名参数的限定类型
+func (s *Segment) checkParam(value string) bool {
+	switch s.paramType {
+	case "int":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case "float":
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	default:
+		return value != ""
+	}
+}
+
+// 获取或创建子节点
+func (s *Segment) child(name string) *Segment {
+	typ, pName, pType := parseSegName(name)
+
+	switch typ {
+	case segParam:
+		for _, v := range s.Params {
+			if v.Name == name {
+				return v
+			}
+		}
+	case segWild:
+		if s.Wild != nil {
+			if s.Wild.Name != name {
+				bufWriter.Fatal("同级路由通配符名称冲突", segToRoutePath(s.Wild), name)
+			}
+			return s.Wild
+		}
+	default:
+		if v, ok := s.Child[name]; ok {
+			return v
+		}
+	}
+
+	seg := &Segment{
+		Name:      name,
+		Routers:   make(map[string]*Router),
+		Parent:    s,
+		Child:     make(map[string]*Segment),
+		param:     pName,
+		paramType: pType,
+	}
+
+	switch typ {
+	case segParam:
+		s.Params = append(s.Params, seg)
+		slices.SortStableFunc(s.Params, func(a, b *Segment) int {
+			switch {
+			case a.paramType != "" && b.paramType == "":
+				return -1
+			case a.paramType == "" && b.paramType != "":
+				return 1
+			default:
+				return 0
+			}
+		})
+	case segWild:
+		s.Wild = seg
+	default:
+		s.Child[name] = seg
+	}
+
+	return seg
+}
+
+// 遍历全部子节点
+func (s *Segment) children() []*Segment {
+	ret := make([]*Segment, 0, len(s.Child)+len(s.Params)+1)
+	for _, v := range s.Child {
+		ret = append(ret, v)
+	}
+	ret = append(ret, s.Params...)
+	if s.Wild != nil {
+		ret = append(ret, s.Wild)
+	}
+
+	return ret
+}
+
+// Router 根据请求方法获取处理规则
+// HEAD请求未单独绑定时使用GET规则 未匹配到请求方法时使用不限制请求方法的规则
+func (s *Segment) Router(method string) *Router {
+	if r, ok := s.Routers[method]; ok {
+		return r
+	}
+
+	if method == http.MethodHead {
+		if r, ok := s.Routers[http.MethodGet]; ok {
+			return r
+		}
+	}
+
+	return s.Routers[MethodAny]
+}
+
+// Allow 返回该路由允许的请求方法 用于405响应的Allow头
+func (s *Segment) Allow() []string {
+	if _, ok := s.Routers[MethodAny]; ok {
+		return nil
+	}
+
+	allow := make([]string, 0, len(s.Routers)+2)
+	for k := range s.Routers {
+		allow = append(allow, k)
+	}
+
+	if slices.Contains(allow, http.MethodGet) && !slices.Contains(allow, http.MethodHead) {
+		allow = append(allow, http.MethodHead)
+	}
+
+	if !slices.Contains(allow, http.MethodOptions) {
+		allow = append(allow, http.MethodOptions)
+	}
+
+	slices.Sort(allow)
+
+	return allow
 }
 
 // 生成路由匹配规则
-func addSegment(path string, router *Router) {
-	s := strings.Split(path, "/")
+func addSegment(router *Router) {
+	s := strings.Split(router.Path, "/")
+
+	for i := 0; i < len(s)-1; i++ {
+		if typ, _, _ := parseSegName(s[i]); typ == segWild {
+			bufWriter.Fatal("通配符只能作为路由的最后一节", router.Path)
+		}
+	}
 
 	parent := rootSegment
 	for i := range s {
-		if _, ok := parent.Child[s[i]]; ok {
-			parent = parent.Child[s[i]]
-			continue
-		}
+		parent = parent.child(s[i])
+	}
 
-		parent.Child[s[i]] = &Segment{
-			Name:   s[i],
-			Router: router,
-			Parent: parent,
-			Child:  make(map[string]*Segment),
-		}
+	parent.addRouter(router)
+}
+
+// 按请求方法保存处理规则
+func (s *Segment) addRouter(router *Router) {
+	methods := router.Methods
+	if len(methods) == 0 {
+		methods = []string{MethodAny}
+	}
 
-		parent = parent.Child[s[i]]
+	for _, m := range methods {
+		if old, ok := s.Routers[m]; ok {
+			bufWriter.Warn("路由规则被覆盖", router.Path, m, old.Handler.StructFuncName, "=>", router.Handler.StructFuncName)
+		}
+		s.Routers[m] = router
 	}
 }
 
 // MatchHandleFunc 匹配路由查找对应方法
-func MatchHandleFunc(urlPath string) (*Segment, []string) {
+// 返回匹配的节点 调用参数 命名参数
+// 调用参数为匹配节点之后剩余的路由单节 命名参数和通配符的值通过ctx.Params获取
+// 路径匹配但不允许该请求方法的节点会被跳过 继续回溯查找 都不允许时返回第一个路径匹配的节点 由调用方返回405
+func MatchHandleFunc(method, urlPath string) (*Segment, []string, context.Params) {
 	if strings.HasSuffix(urlPath, "/") {
 		urlPath = urlPath[0 : len(urlPath)-1]
 	}
@@ -49,37 +222,100 @@ func MatchHandleFunc(urlPath string) (*Segment, []string) {
 		urlPath = urlPath[1:]
 	}
 
-	s := strings.Split(urlPath, "/")
+	m := &matcher{method: method, params: make(context.Params, 0)}
+	if seg, rest := m.match(rootSegment, strings.Split(urlPath, "/")); seg != nil {
+		return seg, rest, m.params
+	}
 
-	var sig *Segment
-	temp := rootSegment
-	j := 0
-	for i := range s { //将urlPath一层层比对
-		j = i
-		if _, ok := temp.Child[s[i]]; !ok {
-			//检测同级别下是否有通配符
-			if _, ok = temp.Child["*"]; ok {
-				return temp.Child["*"], []string{}
-			}
+	//链表路由未匹配时 尝试正则路由
+	if seg, args, params := m.matchRegexp(urlPath); seg != nil {
+		return seg, args, params
+	}
 
-			break
+	// 检测是否有全匹配规则
+	if seg := rootSegment.Wild; seg != nil && len(seg.Routers) > 0 {
+		params := make(context.Params, 0, 1)
+		if seg.param != "" {
+			params = append(params, context.Param{Key: seg.param, Value: urlPath})
 		}
 
-		if len(temp.Child[s[i]].Child) == 0 {
-			sig = temp.Child[s[i]]
-			break
+		if m.accept(seg, nil, params) {
+			return seg, nil, params
 		}
+	}
+
+	return m.fallback, m.fallbackRest, m.fallbackParams
+}
 
-		temp = temp.Child[s[i]]
+// 单次路由匹配的状态
+type matcher struct {
+	method string
+	params context.Params
+
+	//第一个路径匹配但不允许该请求方法的节点 均不允许时用于返回405
+	fallback       *Segment
+	fallbackRest   []string
+	fallbackParams context.Params
+}
+
+// 节点是否允许该请求方法 不允许时记录为备选
+func (m *matcher) accept(seg *Segment, rest []string, params context.Params) bool {
+	if seg.Router(m.method) != nil {
+		return true
 	}
 
-	if sig != nil {
-		return sig, s[j+1:]
+	if m.fallback == nil {
+		m.fallback, m.fallbackRest, m.fallbackParams = seg, rest, slices.Clone(params)
 	}
 
-	// 检测是否有全匹配规则
-	if _, ok := rootSegment.Child["*"]; ok {
-		return rootSegment.Child["*"], []string{}
+	return false
+}
+
+// 按 静态单节 > 命名参数 > 通配符 的优先级逐级匹配 子节点未匹配时回溯
+// 都未匹配时 若当前节点存在处理规则 则剩余单节作为调用参数
+func (m *matcher) match(s *Segment, parts []string) (*Segment, []string) {
+	if len(parts) == 0 {
+		if len(s.Routers) > 0 && m.accept(s, nil, m.params) {
+			return s, nil
+		}
+
+		return nil, nil
+	}
+
+	if c, ok := s.Child[parts[0]]; ok {
+		if seg, rest := m.match(c, parts[1:]); seg != nil {
+			return seg, rest
+		}
+	}
+
+	for _, c := range s.Params {
+		if !c.checkParam(parts[0]) {
+			continue
+		}
+
+		n := len(m.params)
+		m.params = append(m.params, context.Param{Key: c.param, Value: parts[0]})
+		if seg, rest := m.match(c, parts[1:]); seg != nil {
+			return seg, rest
+		}
+		m.params = m.params[:n]
+	}
+
+	//根节点的通配符 在正则路由之后匹配
+	if s.Wild != nil && len(s.Wild.Routers) > 0 && s != rootSegment {
+		n := len(m.params)
+		if s.Wild.param != "" {
+			m.params = append(m.params, context.Param{Key: s.Wild.param, Value: strings.Join(parts, "/")})
+		}
+
+		if m.accept(s.Wild, nil, m.params) {
+			return s.Wild, nil
+		}
+		m.params = m.params[:n]
+	}
+
+	if s != rootSegment && len(s.Routers) > 0 && m.accept(s, parts, m.params) {
+		return s, parts
 	}
 
 	return nil, nil
@@ -87,44 +323,44 @@ func MatchHandleFunc(urlPath string) (*Segment, []string) {
 
 // 根节点
 var rootSegment = &Segment{
-	Name:   "/",
-	Router: nil,
-	Parent: nil,
-	Child:  make(map[string]*Segment),
+	Name:    "/",
+	Routers: make(map[string]*Router),
+	Parent:  nil,
+	Child:   make(map[string]*Segment),
 }
 
 // InitRouterSegment 初始化链表路由规则
 func InitRouterSegment() {
 	initLastHandlerFunc()
 
-	for k := range routers {
-		addSegment(k, routers[k])
+	for _, v := range routers {
+		v.Stats = newRouteStats()
+		if v.isRegexp {
+			addRegexp(v)
+		} else {
+			addSegment(v)
+		}
 	}
 
 	printSegment()
+
+	publishStats()
 }
 
 // 将当前适配的规则写入配置文件
 func printSegment() {
-	_ = os.Remove(appPath.ConfigDir() + "router.txt")
-	pSeg(rootSegment)
-}
-
-// 递归打印每个可适配路由规则
-func pSeg(seg *Segment) {
-	if len(seg.Child) > 0 {
-		for _, v := range seg.Child {
-			pSeg(v)
-		}
-	} else {
-		_ = cFunc.WriteFile(appPath.ConfigDir()+"router.txt", []byte(segToRoutePath(seg)+" ==> "+seg.Router.Handler.StructFuncName+"\n"))
+	var buf bytes.Buffer
+	for _, v := range Routes() {
+		buf.WriteString(v.Path + " [" + strings.Join(v.Methods, ",") + "] ==> " + v.StructFuncName + "\n")
 	}
+
+	_ = os.WriteFile(appPath.ConfigDir()+"router.txt", buf.Bytes(), 0644)
 }
 
 // 将路由链表转为路由字符串 反向查找
 func segToRoutePath(seg *Segment) string {
 	p := make([]string, 0)
-	for i := seg; i.Parent != nil; i = i.Parent {
+	for i := seg; i != nil && i != rootSegment; i = i.Parent {
 		p = append(p, i.Name)
 	}
 
@@ -0,0 +1,256 @@
+package router
+
+import (
+	"github.com/solaa51/swagger/context"
+	"github.com/solaa51/swagger/handle/handleFuncParse"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// 重置路由表后按bind绑定 生成路由链表和正则路由
+func setupRouters(bind func(r *RouteParse)) {
+	rootSegment = &Segment{
+		Name:    "/",
+		Routers: make(map[string]*Router),
+		Child:   make(map[string]*Segment),
+	}
+	regRouters = regRouters[:0]
+	routers = routers[:0]
+
+	bind(&RouteParse{})
+
+	for _, v := range routers {
+		v.Stats = newRouteStats()
+		if v.isRegexp {
+			addRegexp(v)
+		} else {
+			addSegment(v)
+		}
+	}
+}
+
+func nop(*context.Context) {}
+
+// 调用时记录收到的参数
+var received []any
+
+type testUser struct{}
+
+type testParams struct {
+	Id   int
+	Name string `param:"name"`
+}
+
+func (u *testUser) Info(ctx *context.Context, id int, name string) {
+	received = []any{id, name}
+}
+
+func (u *testUser) Show(ctx *context.Context, p *testParams) {
+	received = []any{p.Id, p.Name}
+}
+
+// 匹配路由 并按处理流程调用对应方法
+func callRoute(t *testing.T, method, urlPath string) (*Router, []string, context.Params) {
+	t.Helper()
+
+	seg, args, params := MatchHandleFunc(method, urlPath)
+	if seg == nil {
+		return nil, nil, nil
+	}
+
+	rt := seg.Router(method)
+	if rt == nil {
+		return nil, args, params
+	}
+
+	if err := rt.Handler.Call(&context.Context{Params: params}, rt.FitArgs(args)...); err != nil {
+		t.Fatalf("%s %s 调用失败: %v", method, urlPath, err)
+	}
+
+	return rt, args, params
+}
+
+func TestMatchHandleFunc(t *testing.T) {
+	setupRouters(func(r *RouteParse) {
+		r.BindStruct(func() handleFuncParse.Control { return &testUser{} }, "user")
+		r.BindStruct(func() handleFuncParse.Control { return &testUser{} }, "u/:id/:name")
+		r.BindFunc("user/me", nop)
+		r.Get("user/:id", nop)
+		r.BindFunc("item/:id<int>", nop)
+		r.BindFunc("item/:price<float>/price", nop)
+		r.BindFunc("item/:name", nop)
+		r.BindFunc("files/*path", nop)
+		r.BindFunc("a/b/c", nop)
+		r.BindFunc("a/:x/d", nop)
+		r.BindFunc("g/:gid/m/:mid", nop)
+		r.BindRegexp(`post/(\d+)-(?P<slug>\w+)`, nop)
+	})
+
+	tests := []struct {
+		url    string
+		path   string //匹配的路由规则 为空表示未匹配
+		args   []string
+		params context.Params
+		recv   []any //方法收到的参数 为空不检查
+	}{
+		{"/user/info/5/x", "user/info", []string{"5", "x"}, nil, []any{5, "x"}},
+		{"/user/info/5/x/", "user/info", []string{"5", "x"}, nil, []any{5, "x"}},
+		{"/u/7/tom/show", "u/:id/:name/show", nil, context.Params{{Key: "id", Value: "7"}, {Key: "name", Value: "tom"}}, []any{7, "tom"}},
+		{"/user/me", "user/me", nil, nil, nil},
+		{"/user/12", "user/:id", nil, context.Params{{Key: "id", Value: "12"}}, nil},
+		{"/item/5", "item/:id<int>", nil, context.Params{{Key: "id", Value: "5"}}, nil},
+		{"/item/abc", "item/:name", nil, context.Params{{Key: "name", Value: "abc"}}, nil},
+		{"/item/1.5/price", "item/:price<float>/price", nil, context.Params{{Key: "price", Value: "1.5"}}, nil},
+		{"/files/a/b/c", "files/*path", nil, context.Params{{Key: "path", Value: "a/b/c"}}, nil},
+		{"/a/b/c", "a/b/c", nil, nil, nil},
+		{"/a/b/d", "a/:x/d", nil, context.Params{{Key: "x", Value: "b"}}, nil},
+		{"/g/1/m/2", "g/:gid/m/:mid", nil, context.Params{{Key: "gid", Value: "1"}, {Key: "mid", Value: "2"}}, nil},
+		{"/post/7-hello", `post/(\d+)-(?P<slug>\w+)`, []string{"7", "hello"}, context.Params{{Key: "1", Value: "7"}, {Key: "slug", Value: "hello"}}, nil},
+		{"/post/x-hello", "", nil, nil, nil},
+		{"/none", "", nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		received = nil
+		rt, args, params := callRoute(t, http.MethodGet, tt.url)
+		if tt.path == "" {
+			if rt != nil {
+				t.Errorf("%s 不应匹配 实际匹配 %s", tt.url, rt.Path)
+			}
+			continue
+		}
+
+		if rt == nil {
+			t.Errorf("%s 未匹配 期望 %s", tt.url, tt.path)
+			continue
+		}
+		if rt.Path != tt.path {
+			t.Errorf("%s 匹配 %s 期望 %s", tt.url, rt.Path, tt.path)
+		}
+		if !slices.Equal(args, tt.args) {
+			t.Errorf("%s 调用参数 %q 期望 %q", tt.url, args, tt.args)
+		}
+		if !slices.Equal(params, tt.params) {
+			t.Errorf("%s 命名参数 %v 期望 %v", tt.url, params, tt.params)
+		}
+		if tt.recv != nil && !slices.Equal(received, tt.recv) {
+			t.Errorf("%s 方法收到 %v 期望 %v", tt.url, received, tt.recv)
+		}
+	}
+}
+
+func TestRootWildcard(t *testing.T) {
+	setupRouters(func(r *RouteParse) {
+		r.BindFunc("user/info", nop)
+		r.BindRegexp(`v(\d+)/ping`, nop)
+		r.BindFunc("*all", nop)
+	})
+
+	tests := []struct {
+		url  string
+		path string
+	}{
+		{"/user/info", "user/info"},
+		{"/v2/ping", `v(\d+)/ping`},
+		{"/other/page", "*all"},
+	}
+
+	for _, tt := range tests {
+		rt, _, params := callRoute(t, http.MethodGet, tt.url)
+		if rt == nil || rt.Path != tt.path {
+			t.Errorf("%s 匹配 %v 期望 %s", tt.url, rt, tt.path)
+		}
+		if tt.path == "*all" {
+			if v, _ := params.Get("all"); v != "other/page" {
+				t.Errorf("%s 通配符参数 %q", tt.url, v)
+			}
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	setupRouters(func(r *RouteParse) {
+		r.Get("order/:id", nop)
+		r.Methods(http.MethodPut, http.MethodPatch).BindFunc("order/:id", nop)
+		r.BindFunc("any", nop)
+		r.Get("user/me", nop)
+		r.Post("user/:id", nop)
+		r.Get("item/:id<int>", nop)
+		r.Post("item/:name", nop)
+		r.Get("files/list", nop)
+		r.Post("files/*path", nop)
+		r.Methods(http.MethodPut).BindRegexp(`post/(\d+)`, nop)
+	})
+
+	seg, _, _ := MatchHandleFunc(http.MethodDelete, "/order/3")
+	if seg == nil {
+		t.Fatal("/order/3 未匹配")
+	}
+
+	for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch} {
+		if seg.Router(m) == nil {
+			t.Errorf("%s 应允许", m)
+		}
+	}
+
+	if seg.Router(http.MethodDelete) != nil {
+		t.Error("DELETE 不应允许")
+	}
+
+	want := []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPatch, http.MethodPut}
+	if allow := seg.Allow(); !slices.Equal(allow, want) {
+		t.Errorf("Allow %v 期望 %v", allow, want)
+	}
+
+	seg, _, _ = MatchHandleFunc(http.MethodDelete, "/any")
+	if seg.Router(http.MethodDelete) == nil || seg.Allow() != nil {
+		t.Error("未限制请求方法的路由应允许全部请求方法")
+	}
+
+	//路径匹配但不允许该请求方法时 继续回溯查找允许的路由 均不允许才返回405
+	tests := []struct {
+		method string
+		url    string
+		path   string
+		params context.Params
+		allow  []string //未匹配到允许的路由时 405响应的Allow
+	}{
+		{http.MethodGet, "/user/me", "user/me", nil, nil},
+		{http.MethodPost, "/user/me", "user/:id", context.Params{{Key: "id", Value: "me"}}, nil},
+		{http.MethodDelete, "/user/me", "", nil, []string{http.MethodGet, http.MethodHead, http.MethodOptions}},
+		{http.MethodPost, "/item/5", "item/:name", context.Params{{Key: "name", Value: "5"}}, nil},
+		{http.MethodGet, "/item/5", "item/:id<int>", context.Params{{Key: "id", Value: "5"}}, nil},
+		{http.MethodPost, "/files/list", "files/*path", context.Params{{Key: "path", Value: "list"}}, nil},
+		{http.MethodHead, "/files/list", "files/list", nil, nil},
+		{http.MethodPut, "/post/3", `post/(\d+)`, context.Params{{Key: "1", Value: "3"}}, nil},
+		{http.MethodDelete, "/post/3", "", nil, []string{http.MethodOptions, http.MethodPut}},
+	}
+
+	for _, tt := range tests {
+		seg, _, params := MatchHandleFunc(tt.method, tt.url)
+		if seg == nil {
+			t.Errorf("%s %s 未匹配", tt.method, tt.url)
+			continue
+		}
+
+		rt := seg.Router(tt.method)
+		if tt.path == "" {
+			if rt != nil {
+				t.Errorf("%s %s 应返回405 实际匹配 %s", tt.method, tt.url, rt.Path)
+			}
+			if allow := seg.Allow(); !slices.Equal(allow, tt.allow) {
+				t.Errorf("%s %s Allow %v 期望 %v", tt.method, tt.url, allow, tt.allow)
+			}
+			continue
+		}
+
+		if rt == nil || rt.Path != tt.path {
+			t.Errorf("%s %s 匹配 %v 期望 %s", tt.method, tt.url, rt, tt.path)
+			continue
+		}
+		if !slices.Equal(params, tt.params) {
+			t.Errorf("%s %s 命名参数 %v 期望 %v", tt.method, tt.url, params, tt.params)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package router
+
+import (
+	"expvar"
+	"github.com/solaa51/swagger/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 路由调用统计 计数均为原子操作 不加锁
+
+// LatencyBuckets 耗时直方图的桶上限 毫秒 超出最后一个桶的计入+Inf
+var LatencyBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// RouteStats 单个路由的调用统计
+type RouteStats struct {
+	requests atomic.Int64
+	inFlight atomic.Int64
+	status   [5]atomic.Int64 //按状态码分类 1xx-5xx
+	buckets  []atomic.Int64  //耗时直方图 非累计 最后一个为+Inf
+	sum      atomic.Int64    //累计耗时 微秒
+}
+
+func newRouteStats() *RouteStats {
+	return &RouteStats{
+		buckets: make([]atomic.Int64, len(LatencyBuckets)+1),
+	}
+}
+
+// Begin 请求开始处理
+func (s *RouteStats) Begin() {
+	s.inFlight.Add(1)
+}
+
+// End 请求处理结束 记录状态码和耗时
+func (s *RouteStats) End(status int, d time.Duration) {
+	s.inFlight.Add(-1)
+	s.requests.Add(1)
+
+	if c := status/100 - 1; c >= 0 && c < len(s.status) {
+		s.status[c].Add(1)
+	}
+
+	s.sum.Add(d.Microseconds())
+
+	ms := float64(d) / float64(time.Millisecond)
+	i := 0
+	for i < len(LatencyBuckets) && ms > LatencyBuckets[i] {
+		i++
+	}
+	s.buckets[i].Add(1)
+}
+
+// Bucket 耗时直方图的桶 Count为累计值 包含小于Le的全部请求
+type Bucket struct {
+	Le    string `json:"le"` //桶上限 毫秒 +Inf表示无上限
+	Count int64  `json:"count"`
+}
+
+// StatsSnapshot 调用统计快照
+type StatsSnapshot struct {
+	Requests int64            `json:"requests"` //已完成的请求数
+	InFlight int64            `json:"inFlight"` //处理中的请求数
+	Status   map[string]int64 `json:"status"`   //按状态码分类的请求数 2xx 4xx 5xx等
+	Buckets  []Bucket         `json:"buckets"`  //耗时直方图
+	SumMs    float64          `json:"sumMs"`    //累计耗时 毫秒
+	AvgMs    float64          `json:"avgMs"`    //平均耗时 毫秒
+}
+
+// Snapshot 获取当前统计快照
+func (s *RouteStats) Snapshot() StatsSnapshot {
+	ss := StatsSnapshot{
+		Requests: s.requests.Load(),
+		InFlight: s.inFlight.Load(),
+		Status:   make(map[string]int64, len(s.status)),
+		Buckets:  make([]Bucket, len(s.buckets)),
+		SumMs:    float64(s.sum.Load()) / 1000,
+	}
+
+	for i := range s.status {
+		ss.Status[strconv.Itoa(i+1)+"xx"] = s.status[i].Load()
+	}
+
+	var total int64
+	for i := range s.buckets {
+		total += s.buckets[i].Load()
+		le := "+Inf"
+		if i < len(LatencyBuckets) {
+			le = strconv.FormatFloat(LatencyBuckets[i], 'f', -1, 64)
+		}
+		ss.Buckets[i] = Bucket{Le: le, Count: total}
+	}
+
+	if ss.Requests > 0 {
+		ss.AvgMs = ss.SumMs / float64(ss.Requests)
+	}
+
+	return ss
+}
+
+// RouteStat 路由及其调用统计
+type RouteStat struct {
+	RouteInfo
+	Stats StatsSnapshot `json:"stats"`
+}
+
+// Stats 返回全部路由的调用统计 顺序同Routes()
+func Stats() []RouteStat {
+	items := routeList()
+	ret := make([]RouteStat, 0, len(items))
+	for _, v := range items {
+		ret = append(ret, RouteStat{
+			RouteInfo: v.info,
+			Stats:     v.router.Stats.Snapshot(),
+		})
+	}
+
+	return ret
+}
+
+var publishOnce sync.Once
+
+// 将路由调用统计发布到expvar 通过/debug/var查看
+func publishStats() {
+	publishOnce.Do(func() {
+		expvar.Publish("routeStats", expvar.Func(func() any {
+			return Stats()
+		}))
+	})
+}
+
+func init() {
+	metrics.Register(metrics.CollectorFunc(collectStats))
+}
+
+// 输出路由调用统计的监控指标 同一路径按请求方法分别绑定时以method区分
+func collectStats(w *metrics.Writer) {
+	stats := Stats()
+	labels := make([][]string, len(stats))
+	for i, v := range stats {
+		labels[i] = []string{"path", v.Path, "method", strings.Join(v.Methods, ","), "struct_func_name", v.StructFuncName}
+	}
+
+	w.Family("swagger_http_requests_total", "counter", "按路由和状态码分类的请求数")
+	for i, v := range stats {
+		for c := 1; c <= 5; c++ {
+			class := strconv.Itoa(c) + "xx"
+			if n := v.Stats.Status[class]; n > 0 {
+				w.Sample("swagger_http_requests_total", float64(n), append(labels[i], "status_class", class)...)
+			}
+		}
+	}
+
+	w.Family("swagger_http_requests_in_flight", "gauge", "处理中的请求数")
+	for i, v := range stats {
+		w.Sample("swagger_http_requests_in_flight", float64(v.Stats.InFlight), labels[i]...)
+	}
+
+	w.Family("swagger_http_request_duration_seconds", "histogram", "请求处理耗时")
+	for i, v := range stats {
+		for j, b := range v.Stats.Buckets {
+			le := b.Le
+			if j < len(LatencyBuckets) {
+				le = strconv.FormatFloat(LatencyBuckets[j]/1000, 'g', -1, 64)
+			}
+			w.Sample("swagger_http_request_duration_seconds_bucket", float64(b.Count), append(labels[i], "le", le)...)
+		}
+		w.Sample("swagger_http_request_duration_seconds_sum", v.Stats.SumMs/1000, labels[i]...)
+		w.Sample("swagger_http_request_duration_seconds_count", float64(v.Stats.Requests), labels[i]...)
+	}
+}
@@ -0,0 +1,34 @@
+package router
+
+import (
+	"bytes"
+	"github.com/solaa51/swagger/metrics"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 同一路径按请求方法分别绑定时 监控指标以method区分
+func TestCollectStats(t *testing.T) {
+	setupRouters(func(r *RouteParse) {
+		r.Get("x", nop)
+		r.Post("x", nop)
+	})
+
+	for _, v := range routers {
+		v.Stats.Begin()
+		v.Stats.End(http.StatusOK, time.Millisecond)
+	}
+
+	var buf bytes.Buffer
+	metrics.WriteTo(&buf)
+	out := buf.String()
+
+	for _, m := range []string{http.MethodGet, http.MethodPost} {
+		want := `swagger_http_requests_total{path="/x",method="` + m + `",struct_func_name="x",status_class="2xx"} 1`
+		if !strings.Contains(out, want) {
+			t.Errorf("缺少 %s\n%s", want, out)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/solaa51/swagger/cFunc"
 	"github.com/solaa51/swagger/log/bufWriter"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -13,10 +14,16 @@ type watchFile struct {
 	ch      []chan struct{}
 }
 
-var watchFiles map[string]*watchFile
+var (
+	lock       sync.Mutex
+	watchFiles map[string]*watchFile
+)
 
-// AddWatch 添加监控文件 返回channel便于发送通知
+// AddWatch 添加监控文件 返回channel便于发送通知 可在运行中调用
 func AddWatch(filePath string) (chan struct{}, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
 	n := make(chan struct{})
 	if _, ok := watchFiles[filePath]; !ok {
 		var modTime int64
@@ -41,6 +48,41 @@ func AddWatch(filePath string) (chan struct{}, error) {
 	return n, nil
 }
 
+// 检查文件变更 返回需要通知的channel 通知在锁外发送 避免阻塞AddWatch
+func changed() []chan struct{} {
+	lock.Lock()
+	defer lock.Unlock()
+
+	var notify []chan struct{}
+	for k, v := range watchFiles {
+		if f, err := os.Stat(k); err != nil {
+			//记录错误日志
+			//bufWriter.Error("文件变更监听错误："+k+" ", err)
+			continue
+		} else {
+			//先检查文件变更时间
+			if f.ModTime().Unix() != v.modTime {
+				v.modTime = f.ModTime().Unix()
+
+				fileMd5, err := cFunc.Md5File(k)
+				if err != nil {
+					//记录错误日志
+					bufWriter.Error("文件变更监听错误："+k+" ", err)
+					continue
+				}
+
+				//再检查文件md5值变更
+				if fileMd5 != v.md5 {
+					v.md5 = fileMd5
+					notify = append(notify, v.ch...)
+				}
+			}
+		}
+	}
+
+	return notify
+}
+
 func init() {
 	watchFiles = make(map[string]*watchFile)
 
@@ -49,34 +91,9 @@ func init() {
 		for {
 			select {
 			case <-t.C:
-				for k, v := range watchFiles {
-					if f, err := os.Stat(k); err != nil {
-						//记录错误日志
-						//bufWriter.Error("文件变更监听错误："+k+" ", err)
-						continue
-					} else {
-						//先检查文件变更时间
-						if f.ModTime().Unix() != v.modTime {
-							v.modTime = f.ModTime().Unix()
-
-							fileMd5, err := cFunc.Md5File(k)
-							if err != nil {
-								//记录错误日志
-								bufWriter.Error("文件变更监听错误："+k+" ", err)
-								continue
-							}
-
-							//再检查文件md5值变更
-							if fileMd5 != v.md5 {
-								v.md5 = fileMd5
-								//发送消息
-								//fmt.Println("发送变更通知")
-								for _, c := range v.ch {
-									c <- struct{}{}
-								}
-							}
-						}
-					}
+				//发送消息
+				for _, c := range changed() {
+					c <- struct{}{}
 				}
 			}
 		}